@@ -2,10 +2,15 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
+	"log"
 	"os"
 	"time"
 
+	"github.com/dmholtz/osm-ship-routing/pkg/chokepoint"
 	"github.com/dmholtz/osm-ship-routing/pkg/geometry"
 	"github.com/dmholtz/osm-ship-routing/pkg/graph"
 	"github.com/dmholtz/osm-ship-routing/pkg/grid"
@@ -15,6 +20,9 @@ const density = 710 // parameter for SimpleSphereGrid
 const nTarget = 1e6 // parameter for EquiSphereGrid
 
 func main() {
+	// canals are injected into the grid graph, since the coastline-based grid cannot represent them
+	chokepointFile := flag.String("chokepoints", "chokepoints.geojson", "GeoJSON file of the chokepoints, whose canals are injected into the graph (empty: no canals)")
+	flag.Parse()
 
 	//arg := loadPolyJsonPolygons("antarctica.poly.json")
 	arg := loadPolyJsonPolygons("planet-coastlines.poly.json")
@@ -23,6 +31,8 @@ func main() {
 	grid := grid.NewEquiSphereGrid(nTarget, grid.SIX_NEIGHBORS, arg)
 
 	gridGraph := grid.ToGraph()
+	injectCanals(gridGraph.(*graph.AdjacencyListGraph), *chokepointFile)
+
 	jsonObj, err := json.Marshal(gridGraph)
 	if err != nil {
 		panic(err)
@@ -36,6 +46,23 @@ func main() {
 	graph.WriteFmi(gridGraph, "graph.fmi")
 }
 
+// Inject the canals of the chokepoint file into the graph. A missing file is skipped, since the canals are optional.
+func injectCanals(g *graph.AdjacencyListGraph, file string) {
+	if file == "" {
+		log.Printf("No chokepoint file given, skipping canals")
+		return
+	}
+	chokepoints, err := chokepoint.Load(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Chokepoint file %s not found, skipping canals", file)
+		return
+	}
+	if err != nil {
+		panic(err)
+	}
+	chokepoint.InjectCanals(g, chokepoints)
+}
+
 func loadPolyJsonPolygons(file string) []geometry.Polygon {
 
 	start := time.Now()
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dmholtz/osm-ship-routing/internal/server"
+	"github.com/dmholtz/osm-ship-routing/pkg/coastline"
+
+	"github.com/gorilla/mux"
+)
+
+// The ship routers, graphs and coastlines are published at once after all graphs have been loaded.
+// Until then, the server is not ready and the collections are empty.
+var (
+	stateMutex           sync.RWMutex
+	ready                bool
+	shipRouterCollection map[string]server.ShipRouter = make(map[string]server.ShipRouter)
+	graphCollection      []server.GraphInfo           = make([]server.GraphInfo, 0)
+	coastlineIndex       *coastline.Index             // nil disables track validation
+)
+
+// Metrics of all ship routers
+var metrics = server.NewMetrics()
+
+// Publish the ship routers, graphs and coastlines and mark the server as ready
+func publish(shipRouters map[string]server.ShipRouter, graphs []server.GraphInfo, coastlines *coastline.Index) {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	shipRouterCollection = shipRouters
+	graphCollection = graphs
+	coastlineIndex = coastlines
+	ready = true
+}
+
+// Look up the ship router referred by the request's path.
+// Writes an error response and returns false iff the server is not ready or the router is unavailable.
+func lookUpShipRouter(w http.ResponseWriter, req *http.Request) (server.ShipRouter, bool) {
+	stateMutex.RLock()
+	defer stateMutex.RUnlock()
+
+	if !ready {
+		writeError(w, server.NewRequestError(server.ErrorCodeNotReady, "", "the graphs are still loading"))
+		return nil, false
+	}
+
+	routerName := mux.Vars(req)["router"]
+
+	// filter out invalid or unavailable routers
+	shipRouter, ok := shipRouterCollection[routerName]
+	if !ok {
+		writeError(w, server.NewRequestError(server.ErrorCodeUnknownRouter, "router", "router '%s' does not exist", routerName))
+		return nil, false
+	}
+	return shipRouter, true
+}
+
+// Liveness probe: the server is able to handle requests
+func healthz(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("ok\n"))
+}
+
+// Readiness probe: all graphs have been loaded and all ship routers have been built
+func readyz(w http.ResponseWriter, req *http.Request) {
+	stateMutex.RLock()
+	defer stateMutex.RUnlock()
+
+	if !ready {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
+
+// Reports the list of loaded graphs
+func graphs(w http.ResponseWriter, req *http.Request) {
+	stateMutex.RLock()
+	defer stateMutex.RUnlock()
+
+	json.NewEncoder(w).Encode(graphCollection)
+}
+
+// Reports the metrics in the Prometheus text exposition format
+func prometheusMetrics(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := metrics.WritePrometheus(w); err != nil {
+		log.Printf("Writing metrics failed: %v", err)
+	}
+}
+
+// Reports the list of available ship routers
+func routers(w http.ResponseWriter, req *http.Request) {
+	type routerDescription struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	}
+	stateMutex.RLock()
+	defer stateMutex.RUnlock()
+
+	routerList := make([]routerDescription, 0)
+	for id := range shipRouterCollection {
+		name := shipRouterCollection[id].String()
+		routerList = append(routerList, routerDescription{Id: id, Name: name})
+	}
+	json.NewEncoder(w).Encode(routerList)
+}
+
+// Computes a route using the respective ship router
+func computeRoute(w http.ResponseWriter, req *http.Request) {
+	shipRouter, ok := lookUpShipRouter(w, req)
+	if !ok {
+		return
+	}
+
+	// determine query parameter showSearchSpace
+	showSearchSpace := false
+	if sss := req.URL.Query().Get("show-search-space"); sss != "" {
+		if strings.ToLower(sss) == "true" {
+			showSearchSpace = true
+		}
+	}
+
+	// determine output format from query parameter format or Accept header
+	format, ok := server.NegotiateFormat(req.URL.Query().Get("format"), req.Header.Get("Accept"))
+	if !ok {
+		writeError(w, server.NewRequestError(server.ErrorCodeUnsupportedFormat, "format", "none of the requested output formats is supported"))
+		return
+	}
+	encoder, _ := server.GetRouteEncoder(format)
+
+	// extract RouteRequest from request body
+	var routeRequest server.RouteRequest
+	err := json.NewDecoder(req.Body).Decode(&routeRequest)
+	if err != nil {
+		writeError(w, server.NewRequestError(server.ErrorCodeInvalidRequest, "", "%s", err))
+		return
+	}
+	// the query parameter vessel takes precedence over the vessel of the request body
+	if vessel := req.URL.Query().Get("vessel"); vessel != "" {
+		routeRequest.Vessel = vessel
+	}
+
+	// processing
+	log.Printf("Processing RouteRequest %v with searchSpace=%t", routeRequest, showSearchSpace)
+	routeResponse, err := shipRouter.ProcessRequest(req.Context(), routeRequest, showSearchSpace)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", encoder.ContentType)
+	err = encoder.Encode(w, routeResponse)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// Computes the lengths of the shortest paths between all pairs of sources and targets
+func computeMatrix(w http.ResponseWriter, req *http.Request) {
+	shipRouter, ok := lookUpShipRouter(w, req)
+	if !ok {
+		return
+	}
+
+	// extract MatrixRequest from request body
+	var matrixRequest server.MatrixRequest
+	err := json.NewDecoder(req.Body).Decode(&matrixRequest)
+	if err != nil {
+		writeError(w, server.NewRequestError(server.ErrorCodeInvalidRequest, "", "%s", err))
+		return
+	}
+
+	// processing
+	log.Printf("Processing MatrixRequest with %d sources and %d targets", len(matrixRequest.Sources), len(matrixRequest.Targets))
+	matrixResponse, err := shipRouter.ProcessMatrixRequest(req.Context(), matrixRequest)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(matrixResponse)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// Computes the nodes being reachable from an origin within given distance bands
+func computeIsochrone(w http.ResponseWriter, req *http.Request) {
+	shipRouter, ok := lookUpShipRouter(w, req)
+	if !ok {
+		return
+	}
+
+	// extract IsochroneRequest from request body
+	var isochroneRequest server.IsochroneRequest
+	err := json.NewDecoder(req.Body).Decode(&isochroneRequest)
+	if err != nil {
+		writeError(w, server.NewRequestError(server.ErrorCodeInvalidRequest, "", "%s", err))
+		return
+	}
+	// processing
+	log.Printf("Processing IsochroneRequest %v", isochroneRequest)
+	isochroneResponse, err := shipRouter.ProcessIsochroneRequest(req.Context(), isochroneRequest)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	err = server.EncodeIsochroneGeoJSON(w, isochroneResponse)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// Computes the best route and alternative routes between origin and destination
+func computeAlternatives(w http.ResponseWriter, req *http.Request) {
+	shipRouter, ok := lookUpShipRouter(w, req)
+	if !ok {
+		return
+	}
+
+	// extract AlternativesRequest from request body
+	var alternativesRequest server.AlternativesRequest
+	err := json.NewDecoder(req.Body).Decode(&alternativesRequest)
+	if err != nil {
+		writeError(w, server.NewRequestError(server.ErrorCodeInvalidRequest, "", "%s", err))
+		return
+	}
+
+	// processing
+	log.Printf("Processing AlternativesRequest %v", alternativesRequest)
+	alternativesResponse, err := shipRouter.ProcessAlternativesRequest(req.Context(), alternativesRequest)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(alternativesResponse)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// Checks the legs of a track against the coastlines, independently of the ship routers
+func validateTrack(w http.ResponseWriter, req *http.Request) {
+	stateMutex.RLock()
+	isReady, coastlines := ready, coastlineIndex
+	stateMutex.RUnlock()
+	if !isReady {
+		writeError(w, server.NewRequestError(server.ErrorCodeNotReady, "", "the graphs are still loading"))
+		return
+	}
+
+	// extract ValidationRequest from request body
+	var validationRequest server.ValidationRequest
+	err := json.NewDecoder(req.Body).Decode(&validationRequest)
+	if err != nil {
+		writeError(w, server.NewRequestError(server.ErrorCodeInvalidRequest, "", "%s", err))
+		return
+	}
+
+	// processing
+	log.Printf("Processing ValidationRequest with %d waypoints", len(validationRequest.Waypoints))
+	validationResponse, err := server.ValidateTrack(req.Context(), coastlines, validationRequest)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(validationResponse)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// Non-standard status code for requests whose processing has been aborted because the client closed the connection
+const statusClientClosedRequest = 499
+
+// HTTP status codes of the error codes
+var errorStatus = map[string]int{
+	server.ErrorCodeInvalidRequest:     http.StatusBadRequest,
+	server.ErrorCodeInvalidCoordinates: http.StatusBadRequest,
+	server.ErrorCodeInvalidValue:       http.StatusBadRequest,
+	server.ErrorCodePointOnLand:        http.StatusUnprocessableEntity,
+	server.ErrorCodeUnreachable:        http.StatusUnprocessableEntity,
+	server.ErrorCodePointInAvoidArea:   http.StatusUnprocessableEntity,
+	server.ErrorCodeRestrictedPoint:    http.StatusUnprocessableEntity,
+	server.ErrorCodeUnknownRouter:      http.StatusNotFound,
+	server.ErrorCodeUnsupportedFormat:  http.StatusNotAcceptable,
+	server.ErrorCodeNotReady:           http.StatusServiceUnavailable,
+	server.ErrorCodeNotAvailable:       http.StatusNotImplemented,
+	server.ErrorCodeTimeout:            http.StatusServiceUnavailable,
+	server.ErrorCodeCancelled:          statusClientClosedRequest,
+	server.ErrorCodeInternal:           http.StatusInternalServerError,
+}
+
+// Writes a structured error response.
+// Errors of aborted requests are reported as timeout or cancelled, any other error that is not a *server.RequestError as internal error.
+func writeError(w http.ResponseWriter, err error) {
+	var reqErr *server.RequestError
+	switch {
+	case errors.As(err, &reqErr):
+	case errors.Is(err, context.DeadlineExceeded):
+		reqErr = server.NewRequestError(server.ErrorCodeTimeout, "", "processing the request exceeded the request timeout")
+	case errors.Is(err, context.Canceled):
+		// the client has disconnected or the server is shutting down
+		reqErr = server.NewRequestError(server.ErrorCodeCancelled, "", "processing the request has been cancelled")
+	default:
+		reqErr = server.NewRequestError(server.ErrorCodeInternal, "", "%s", err)
+	}
+
+	status, ok := errorStatus[reqErr.Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	if status >= http.StatusInternalServerError || status == statusClientClosedRequest {
+		log.Printf("Processing failed: %v", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(reqErr)
+}
+
+// Limits the processing time of each request to the configured request timeout
+func timeoutMiddleware(config server.Config) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if config.RequestTimeout <= 0 {
+			return next
+		}
+		timeout := time.Duration(config.RequestTimeout) * time.Second
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+// Records the number, latency and errors of the requests to the endpoints of the ship routers.
+// The endpoint is identified by the name of the route.
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		routerId, ok := mux.Vars(req)["router"]
+		if !ok {
+			next.ServeHTTP(w, req)
+			return
+		}
+		endpoint := mux.CurrentRoute(req).GetName()
+
+		startTime := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, req)
+
+		if !isLoadedRouter(routerId) {
+			// do not create a time series for each unknown router id, including requests before the server is ready
+			routerId = "unknown"
+		}
+		metrics.ObserveRequest(routerId, endpoint, time.Since(startTime))
+		if recorder.status >= 400 {
+			metrics.ObserveError(routerId, endpoint, recorder.status)
+		}
+	})
+}
+
+// Reports whether the ship router with the given id has been loaded
+func isLoadedRouter(routerId string) bool {
+	stateMutex.RLock()
+	defer stateMutex.RUnlock()
+
+	_, ok := shipRouterCollection[routerId]
+	return ok
+}
+
+// Allows cross-origin requests from the configured origins
+func corsMiddleware(config server.Config) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			origin := req.Header.Get("Origin")
+			if len(config.CorsOrigins) == 1 && config.CorsOrigins[0] == "*" {
+				w.Header().Add("Access-Control-Allow-Origin", "*")
+			} else if origin != "" && config.AllowsOrigin(origin) {
+				w.Header().Add("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -1,131 +1,161 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"flag"
 	"log"
-	"math"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"strings"
-
-	sp "github.com/dmholtz/graffiti/algorithms/shortest_path"
-	"github.com/dmholtz/graffiti/examples/io"
-	g "github.com/dmholtz/graffiti/graph"
+	"syscall"
+	"time"
 
 	"github.com/dmholtz/osm-ship-routing/internal/server"
+	"github.com/dmholtz/osm-ship-routing/pkg/chokepoint"
+	"github.com/dmholtz/osm-ship-routing/pkg/coastline"
 
 	"github.com/gorilla/mux"
 )
 
-const graphFile = "graphs/ocean_equi_4_grid_arcflags128.fmi"
-const towLevelGraphFile = "graphs/ocean_equi_4_grid_arcflags32_32.fmi"
+// Size of the cells of the spatial index over the coastlines, unit degree
+const coastlineCellSize = 1.0
 
-var shipRouterCollection map[string]server.ShipRouter = make(map[string]server.ShipRouter)
+func main() {
+	configFile := flag.String("config", "", "JSON configuration file (default: built-in configuration)")
+	address := flag.String("address", "", "listen address of the server, overrides the configuration")
+	landmarks := flag.Int("landmarks", 0, "number of landmarks of the ALT heuristic, overrides the configuration")
+	corsOrigins := flag.String("cors-origins", "", "comma-separated list of allowed origins, overrides the configuration")
+	flag.Parse()
+
+	config := server.DefaultConfig()
+	if *configFile != "" {
+		var err error
+		config, err = server.LoadConfig(*configFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 
-func routerId(name string) string {
-	id := strings.ReplaceAll(name, " ", "-")
-	id = strings.ToLower(id)
-	return id
-}
+	// command line flags take precedence over the configuration file
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "address":
+			config.Address = *address
+		case "landmarks":
+			config.Landmarks = *landmarks
+		case "cors-origins":
+			config.CorsOrigins = strings.Split(*corsOrigins, ",")
+		}
+	})
+	if err := config.Validate(); err != nil {
+		log.Fatal(err)
+	}
 
-// Reports the list of available ship routers
-func routers(w http.ResponseWriter, req *http.Request) {
-	// allow origin
-	w.Header().Add("Access-Control-Allow-Origin", "*")
+	r := mux.NewRouter()
+	r.Use(corsMiddleware(config))
+	r.Use(timeoutMiddleware(config))
+	r.Use(metricsMiddleware)
+	r.HandleFunc("/healthz", healthz).Methods("GET")
+	r.HandleFunc("/readyz", readyz).Methods("GET")
+	r.HandleFunc("/metrics", prometheusMetrics).Methods("GET")
+	r.HandleFunc("/graphs", graphs).Methods("GET")
+	r.HandleFunc("/routers", routers).Methods("GET")
+	r.HandleFunc("/validate", validateTrack).Methods("POST")
+	// route names label the metrics of the endpoints
+	r.HandleFunc("/routers/{router}", computeRoute).Methods("POST").Name("route")
+	r.HandleFunc("/routers/{router}/matrix", computeMatrix).Methods("POST").Name("matrix")
+	r.HandleFunc("/routers/{router}/isochrone", computeIsochrone).Methods("POST").Name("isochrone")
+	r.HandleFunc("/routers/{router}/alternatives", computeAlternatives).Methods("POST").Name("alternatives")
+
+	// the contexts of all requests are derived from baseCtx, which is cancelled if draining the requests on shutdown takes too long
+	baseCtx, abortRequests := context.WithCancel(context.Background())
+	defer abortRequests()
+	server := http.Server{
+		Addr:        config.Address,
+		Handler:     r,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
 
-	type routerDescription struct {
-		Id   string `json:"id"`
-		Name string `json:"name"`
+	// the server accepts requests while the graphs are being loaded, but is not ready until loading has been completed
+	go loadGraphs(config)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server started at %s", config.Address)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	select {
+	case err := <-serverErr:
+		log.Fatal(err)
+	case sig := <-signals:
+		log.Printf("Received %s, shutting down ...", sig)
 	}
-	routerList := make([]routerDescription, 0)
-	for id := range shipRouterCollection {
-		name := shipRouterCollection[id].String()
-		routerList = append(routerList, routerDescription{Id: id, Name: name})
+
+	// stop accepting new requests and wait for in-flight requests to complete
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(config.ShutdownTimeout)*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Aborting in-flight requests: %v", err)
+		abortRequests()
+		server.Close()
 	}
-	json.NewEncoder(w).Encode(routerList)
+	log.Printf("Server stopped")
 }
 
-// Computes a route using the respective ship router
-func computeRoute(w http.ResponseWriter, req *http.Request) {
-	// allow origin
-	w.Header().Add("Access-Control-Allow-Origin", "*")
+// Load all configured graphs, build their ship routers and publish them
+func loadGraphs(config server.Config) {
+	shared := sharedResources{landmarks: config.Landmarks, metrics: metrics, vessels: config.Vessels, safetyMargin: config.SafetyMargin}
 
-	routerName := mux.Vars(req)["router"]
-
-	// filter out invalid or unavailable routers
-	if _, ok := shipRouterCollection[routerName]; !ok {
-		w.WriteHeader(http.StatusNotFound)
-		return
+	// the cache is shared by all ship routers, whose ids are part of the cache key
+	if config.CacheSize > 0 {
+		shared.cache = server.NewRouteCache(config.CacheSize, time.Duration(config.CacheTTL)*time.Second)
 	}
-	shipRouter := shipRouterCollection[routerName]
 
-	// determine query parameter showSearchSpace
-	showSearchSpace := false
-	if sss := req.URL.Query().Get("show-search-space"); sss != "" {
-		if strings.ToLower(sss) == "true" {
-			showSearchSpace = true
+	// the coastlines are shared by all ship routers, since they do not depend on the graph
+	if config.Coastlines != "" {
+		log.Printf("Loading coastlines from file %s ...\n", config.Coastlines)
+		polygons, err := coastline.LoadPolyJson(config.Coastlines)
+		if err != nil {
+			log.Fatal(err)
 		}
+		shared.coastlines = coastline.NewIndex(polygons, coastlineCellSize)
 	}
 
-	// extract RouteRequest from request body
-	var routeRequest server.RouteRequest
-	err := json.NewDecoder(req.Body).Decode(&routeRequest)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+	if config.Zones != "" {
+		log.Printf("Loading penalty zones from file %s ...\n", config.Zones)
+		zones, err := server.LoadZones(config.Zones)
+		if err != nil {
+			log.Fatal(err)
+		}
+		shared.zones = zones
 	}
 
-	// processing
-	log.Printf("Processing RouteRequest %v with searchSpace=%t", routeRequest, showSearchSpace)
-	routeResponse := shipRouter.ProcessRequest(routeRequest, showSearchSpace)
-
-	err = json.NewEncoder(w).Encode(routeResponse)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	if config.Chokepoints != "" {
+		log.Printf("Loading chokepoints from file %s ...\n", config.Chokepoints)
+		chokepoints, err := chokepoint.Load(config.Chokepoints)
+		if err != nil {
+			log.Fatal(err)
+		}
+		shared.chokepoints = chokepoints
 	}
-}
-
-func main() {
-	log.Printf("Loading graph from file %s ...\n", graphFile)
-
-	falg128 := io.NewAdjacencyListFromFmi(graphFile, io.ParsePartGeoPoint, io.ParseLargeFlaggedHalfEdge)
-	faag128 := g.NewAdjacencyArrayFromGraph[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int]](falg128)
-
-	log.Printf("Loading graph from file %s ...\n", towLevelGraphFile)
-
-	falg32 := io.NewAdjacencyListFromFmi(towLevelGraphFile, io.Parse2LPartGeoPoint, io.Parse2LFlaggedHalfEdge)
-	faag32 := g.NewAdjacencyArrayFromGraph[g.TwoLevelPartGeoPoint, g.TwoLevelFlaggedHalfEdge[int, uint64, uint64]](falg32)
-
-	log.Println("Compute ALT heuristic...")
-	landmarks := sp.UniformLandmarks[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int]](faag128, 16)
-	alt16 := sp.NewAltHeurisitc[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int], int](faag128, faag128, landmarks[:16])
-
-	log.Printf("Building router ...\n")
 
-	dijkstraRouter := sp.DijkstraRouter[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int], int]{Graph: faag128}
-	biDijkstraRouter := sp.BiDijkstraRouter[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int], int]{Graph: faag128, Transpose: faag128, MaxInitializerValue: math.MaxInt}
-	arcflag128Router := sp.ArcFlagRouter[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int], int]{Graph: faag128}
-	biArcflag128Router := sp.BidirectionalArcFlagRouter[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int], int]{Graph: faag128, Transpose: faag128, MaxInitializerValue: math.MaxInt}
-	twoLevelArcflagRouter := sp.TwoLevelArcFlagRouter[g.TwoLevelPartGeoPoint, g.TwoLevelFlaggedHalfEdge[int, uint64, uint64], int]{Graph: faag32}
-	altRouter := sp.AStarRouter[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int], int]{Graph: faag128, Heuristic: alt16}
-	arcflagAltRouter := sp.ArcFlagAStarRouter[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int], int]{Graph: faag128, Transpose: faag128, Heuristic: alt16}
-
-	shipRouterCollection[routerId(dijkstraRouter.String())] = server.ShipRouter1[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int]]{Graph: faag128, Router: dijkstraRouter}
-	shipRouterCollection[routerId(biDijkstraRouter.String())] = server.ShipRouter1[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int]]{Graph: faag128, Router: biDijkstraRouter}
-	shipRouterCollection[routerId(arcflag128Router.String())] = server.ShipRouter1[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int]]{Graph: faag128, Router: arcflag128Router}
-	shipRouterCollection[routerId(biArcflag128Router.String())] = server.ShipRouter1[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int]]{Graph: faag128, Router: biArcflag128Router}
-	shipRouterCollection[routerId(twoLevelArcflagRouter.String())] = server.ShipRouter1[g.TwoLevelPartGeoPoint, g.TwoLevelFlaggedHalfEdge[int, uint64, uint64]]{Graph: faag32, Router: twoLevelArcflagRouter}
-	shipRouterCollection[routerId(altRouter.String())] = server.ShipRouter1[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int]]{Graph: faag128, Router: altRouter}
-	shipRouterCollection[routerId(arcflagAltRouter.String())] = server.ShipRouter1[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int]]{Graph: faag128, Router: arcflagAltRouter}
-
-	r := mux.NewRouter()
-	r.HandleFunc("/routers", routers).Methods("GET")
-	r.HandleFunc("/routers/{router}", computeRoute).Methods("POST")
-
-	server := http.Server{
-		Addr:    ":8081",
-		Handler: r,
+	shipRouters := make(map[string]server.ShipRouter)
+	graphInfos := make([]server.GraphInfo, 0, len(config.Graphs))
+	for _, graphConfig := range config.Graphs {
+		graphShipRouters, graphInfo, err := loadShipRouters(graphConfig, shared)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for id, shipRouter := range graphShipRouters {
+			shipRouters[id] = shipRouter
+		}
+		graphInfos = append(graphInfos, graphInfo)
 	}
-	log.Printf("Server started at http://localhost:8081")
-	server.ListenAndServe()
+	publish(shipRouters, graphInfos, shared.coastlines)
+	log.Printf("Server is ready")
 }
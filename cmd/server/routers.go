@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	sp "github.com/dmholtz/graffiti/algorithms/shortest_path"
+	"github.com/dmholtz/graffiti/examples/io"
+	g "github.com/dmholtz/graffiti/graph"
+
+	"github.com/dmholtz/osm-ship-routing/internal/server"
+	"github.com/dmholtz/osm-ship-routing/pkg/chokepoint"
+	"github.com/dmholtz/osm-ship-routing/pkg/coastline"
+	gr "github.com/dmholtz/osm-ship-routing/pkg/graph"
+	"github.com/dmholtz/osm-ship-routing/pkg/grid"
+	"github.com/dmholtz/osm-ship-routing/pkg/routing"
+)
+
+// A routerFactory creates a router of the given type on the graph.
+// The ALT heuristic and the water mask of the grid are nil unless the type of the router requires them.
+type routerFactory[N server.IGeoPoint, E g.IWeightedHalfEdge[int]] func(routerType string, graph g.Graph[N, E], alt sp.Heuristic[int], mask grid.WaterMask) (sp.Router[int], error)
+
+// Resources shared by the ship routers of all graphs
+type sharedResources struct {
+	landmarks    int                     // number of landmarks of the ALT heuristic
+	metrics      *server.Metrics         // records the metrics of all ship routers
+	cache        *server.RouteCache      // nil disables the route cache
+	coastlines   *coastline.Index        // nil disables path smoothing and distances to the coast
+	safetyMargin int                     // minimum distance of each leg to the coast, unit meters
+	zones        []server.Zone           // penalty zones, which are indexed per graph
+	vessels      []server.VesselProfile  // vessel profiles, whose restrictions are computed per graph
+	chokepoints  []chokepoint.Chokepoint // chokepoints, which are located per graph
+}
+
+// Load the graph declared in the configuration and build its ship routers.
+// The node and edge parser determine the node and edge types of the graph and thus the available router types.
+func loadShipRouters(config server.GraphConfig, shared sharedResources) (map[string]server.ShipRouter, server.GraphInfo, error) {
+	switch parsers := config.NodeParser + "/" + config.EdgeParser; parsers {
+	case "ParseGeoPoint/ParseWeightedHalfEdge":
+		return buildShipRouters(config, shared, io.ParseGeoPoint, io.ParseWeightedHalfEdge, newRouter[g.GeoPoint, g.WeightedHalfEdge[int]])
+	case "ParsePartGeoPoint/ParseFlaggedHalfEdge":
+		return buildShipRouters(config, shared, io.ParsePartGeoPoint, io.ParseFlaggedHalfEdge, newArcFlagRouter[g.PartGeoPoint, g.FlaggedHalfEdge[int, uint64]])
+	case "ParsePartGeoPoint/ParseLargeFlaggedHalfEdge":
+		return buildShipRouters(config, shared, io.ParsePartGeoPoint, io.ParseLargeFlaggedHalfEdge, newArcFlagRouter[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int]])
+	case "Parse2LPartGeoPoint/Parse2LFlaggedHalfEdge":
+		return buildShipRouters(config, shared, io.Parse2LPartGeoPoint, io.Parse2LFlaggedHalfEdge, newTwoLevelArcFlagRouter[g.TwoLevelPartGeoPoint, g.TwoLevelFlaggedHalfEdge[int, uint64, uint64]])
+	default:
+		return nil, server.GraphInfo{}, fmt.Errorf("unsupported combination of node and edge parser: %s", parsers)
+	}
+}
+
+func buildShipRouters[N server.IGeoPoint, E g.IWeightedHalfEdge[int]](config server.GraphConfig, shared sharedResources, nodeParser func(string) (int, N), edgeParser func(string) (int, E), factory routerFactory[N, E]) (map[string]server.ShipRouter, server.GraphInfo, error) {
+	startTime := time.Now()
+
+	log.Printf("Loading graph from file %s ...\n", config.File)
+	alg := io.NewAdjacencyListFromFmi(config.File, nodeParser, edgeParser)
+	aag := g.NewAdjacencyArrayFromGraph[N, E](alg)
+
+	log.Printf("Build spatial index for graph %s ...\n", config.File)
+	index := server.NewNodeIndex[N, E](aag)
+
+	// ALT preprocessing is expensive and only done if required by some router
+	var alt sp.Heuristic[int]
+	for _, routerConfig := range config.Routers {
+		if routerConfig.Type == server.AltRouterType || routerConfig.Type == server.ArcFlagAltRouterType {
+			log.Printf("Compute ALT heuristic with %d landmarks for graph %s ...\n", shared.landmarks, config.File)
+			alt = sp.NewAltHeurisitc[N, E, int](aag, aag, sp.UniformLandmarks[N, E](aag, shared.landmarks))
+			break
+		}
+	}
+
+	// the water mask is reconstructed from the nodes of the graph and only required by any-angle routers
+	var mask grid.WaterMask
+	for _, routerConfig := range config.Routers {
+		if routerConfig.Type == server.ThetaStarRouterType && config.Grid != nil {
+			log.Printf("Reconstruct %s of graph %s ...\n", config.Grid.Type, config.File)
+			mask = newWaterMask(*config.Grid, server.AsGraph[N, E](aag))
+			break
+		}
+	}
+
+	var zones *server.ZoneIndex
+	if len(shared.zones) > 0 {
+		log.Printf("Assign edges of graph %s to %d penalty zones ...\n", config.File, len(shared.zones))
+		zones = server.NewZoneIndex[N, E](aag, shared.zones)
+	}
+
+	var chokepoints *server.ChokepointIndex
+	if len(shared.chokepoints) > 0 {
+		log.Printf("Locate %d chokepoints in graph %s ...\n", len(shared.chokepoints), config.File)
+		var err error
+		if chokepoints, err = server.NewChokepointIndex[N, E](aag, index, shared.chokepoints); err != nil {
+			return nil, server.GraphInfo{}, fmt.Errorf("graph %s: %w", config.File, err)
+		}
+		for _, id := range chokepoints.Ids() {
+			if !chokepoints.Contains(id) {
+				log.Printf("Chokepoint %s is not contained in graph %s\n", id, config.File)
+			}
+		}
+	}
+
+	var vessels *server.VesselIndex
+	if len(shared.vessels) > 0 {
+		var restrictions server.Restrictions
+		var err error
+		if config.Restrictions != "" {
+			log.Printf("Loading restrictions of graph %s from file %s ...\n", config.File, config.Restrictions)
+			if restrictions, err = server.LoadRestrictions(config.Restrictions); err != nil {
+				return nil, server.GraphInfo{}, err
+			}
+		}
+		if chokepoints != nil {
+			// the limits of canals and straits apply in addition to the restrictions of the graph
+			located := chokepoints.Restrictions()
+			restrictions.Nodes = append(restrictions.Nodes, located.Nodes...)
+			restrictions.Edges = append(restrictions.Edges, located.Edges...)
+		}
+		log.Printf("Compute restrictions of %d vessel profiles for graph %s ...\n", len(shared.vessels), config.File)
+		if vessels, err = server.NewVesselIndex[N, E](aag, shared.vessels, restrictions); err != nil {
+			return nil, server.GraphInfo{}, fmt.Errorf("graph %s: %w", config.File, err)
+		}
+	}
+
+	shipRouters := make(map[string]server.ShipRouter)
+	routerIds := make([]string, 0, len(config.Routers))
+	for _, routerConfig := range config.Routers {
+		// check once that the router type is supported by the graph, since the ship router creates a router per request
+		if _, err := factory(routerConfig.Type, aag, alt, mask); err != nil {
+			return nil, server.GraphInfo{}, fmt.Errorf("graph %s: %w", config.File, err)
+		}
+		routerType := routerConfig.Type
+		newRouter := func(graph g.Graph[N, E]) sp.Router[int] {
+			router, _ := factory(routerType, graph, alt, mask)
+			return router
+		}
+		log.Printf("Building router %s on graph %s ...\n", routerConfig.RouterId(), config.File)
+		shipRouter := server.NewShipRouter1[N, E](aag, newRouter, index)
+		if restrictedType := restrictedRouterType(routerType); restrictedType != routerType {
+			shipRouter.NewRestrictedRouter = func(graph g.Graph[N, E]) sp.Router[int] {
+				router, _ := factory(restrictedType, graph, alt, mask)
+				return router
+			}
+		}
+		shipRouter.Id = routerConfig.RouterId()
+		shipRouter.Metrics = shared.metrics
+		shipRouter.Cache = shared.cache
+		shipRouter.MaxSnapDistance = config.MaxSnapDistance
+		shipRouter.Coastlines = shared.coastlines
+		shipRouter.SafetyMargin = shared.safetyMargin
+		shipRouter.Zones = zones
+		shipRouter.Vessels = vessels
+		shipRouter.Chokepoints = chokepoints
+		shipRouters[routerConfig.RouterId()] = shipRouter
+		routerIds = append(routerIds, routerConfig.RouterId())
+	}
+	return shipRouters, server.NewGraphInfo[N, E](config.File, aag, routerIds, time.Since(startTime)), nil
+}
+
+// Router type, which replaces routers of the given type on restricted views of the graph.
+// Arc flags are computed on the unrestricted graph and the theta-star router checks the line of sight against the unrestricted grid.
+// In contrast, the ALT heuristic remains a lower bound if nodes are removed from the graph.
+func restrictedRouterType(routerType string) string {
+	switch routerType {
+	case server.ArcFlagRouterType, server.BiArcFlagRouterType, server.TwoLevelArcFlagRouterType, server.ThetaStarRouterType:
+		return server.BiDijkstraRouterType
+	case server.ArcFlagAltRouterType:
+		return server.AltRouterType
+	default:
+		return routerType
+	}
+}
+
+// Reconstruct the grid, from which the graph has been built
+func newWaterMask(config server.GridConfig, graph gr.Graph) grid.WaterMask {
+	switch config.Type {
+	case server.SimpleSphereGridType:
+		return grid.NewSimpleSphereGridFromGraph(config.NLon, config.NLat, graph)
+	default:
+		// the mesh type does not affect the land / water data
+		return grid.NewEquiSphereGridFromGraph(config.NTarget, grid.FOUR_NEIGHBORS, graph)
+	}
+}
+
+// Create routers that only require a weighted graph.
+// Note that the graph is undirected and thus its own transpose.
+func newRouter[N server.IGeoPoint, E g.IWeightedHalfEdge[int]](routerType string, graph g.Graph[N, E], alt sp.Heuristic[int], mask grid.WaterMask) (sp.Router[int], error) {
+	switch routerType {
+	case server.DijkstraRouterType:
+		return sp.DijkstraRouter[N, E, int]{Graph: graph}, nil
+	case server.BiDijkstraRouterType:
+		return sp.BiDijkstraRouter[N, E, int]{Graph: graph, Transpose: graph, MaxInitializerValue: math.MaxInt}, nil
+	case server.AltRouterType:
+		return sp.AStarRouter[N, E, int]{Graph: graph, Heuristic: alt}, nil
+	case server.ThetaStarRouterType:
+		if mask == nil {
+			return nil, fmt.Errorf("router type %s requires the grid of the graph", routerType)
+		}
+		return routing.ThetaStarRouter{Graph: server.AsGraph[N, E](graph), Mask: mask}, nil
+	default:
+		return nil, fmt.Errorf("router type %s is not supported by the graph", routerType)
+	}
+}
+
+// Create routers that require a partitioned graph with arc flags
+func newArcFlagRouter[N interface {
+	server.IGeoPoint
+	g.Partitioner
+}, E g.IFlaggedHalfEdge[int]](routerType string, graph g.Graph[N, E], alt sp.Heuristic[int], mask grid.WaterMask) (sp.Router[int], error) {
+	switch routerType {
+	case server.ArcFlagRouterType:
+		return sp.ArcFlagRouter[N, E, int]{Graph: graph}, nil
+	case server.BiArcFlagRouterType:
+		return sp.BidirectionalArcFlagRouter[N, E, int]{Graph: graph, Transpose: graph, MaxInitializerValue: math.MaxInt}, nil
+	case server.ArcFlagAltRouterType:
+		return sp.ArcFlagAStarRouter[N, E, int]{Graph: graph, Transpose: graph, Heuristic: alt}, nil
+	default:
+		return newRouter[N, E](routerType, graph, alt, mask)
+	}
+}
+
+// Create routers that require a two-level partitioned graph with two-level arc flags
+func newTwoLevelArcFlagRouter[N interface {
+	server.IGeoPoint
+	g.TwoLevelPartitioner
+}, E g.ITwoLevelFlaggedHalfEdge[int]](routerType string, graph g.Graph[N, E], alt sp.Heuristic[int], mask grid.WaterMask) (sp.Router[int], error) {
+	switch routerType {
+	case server.TwoLevelArcFlagRouterType:
+		return sp.TwoLevelArcFlagRouter[N, E, int]{Graph: graph}, nil
+	default:
+		return newRouter[N, E](routerType, graph, alt, mask)
+	}
+}
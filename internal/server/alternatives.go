@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+// Defaults and limits of alternatives requests
+const (
+	DefaultAlternatives = 3
+	MaxAlternatives     = 10
+	DefaultMaxStretch   = 1.5
+	DefaultMaxOverlap   = 0.8
+)
+
+// Factor by which the weights of the edges of each computed path are increased by the penalty method
+const alternativePenalty = 1.4
+
+// Validate checks the coordinates of origin and destination and the ranges of the parameters
+func (req AlternativesRequest) Validate() error {
+	if err := req.Origin.Validate("origin"); err != nil {
+		return err
+	}
+	if err := req.Destination.Validate("destination"); err != nil {
+		return err
+	}
+	if req.Alternatives < 0 || req.Alternatives > MaxAlternatives {
+		return NewRequestError(ErrorCodeInvalidValue, "alternatives", "number of routes %d is not in [0, %d], 0 for the default", req.Alternatives, MaxAlternatives)
+	}
+	if math.IsNaN(req.MaxStretch) || (req.MaxStretch != 0 && req.MaxStretch < 1) {
+		return NewRequestError(ErrorCodeInvalidValue, "max_stretch", "maximum stretch %v must be at least 1", req.MaxStretch)
+	}
+	if math.IsNaN(req.MaxOverlap) || req.MaxOverlap < 0 || req.MaxOverlap >= 1 {
+		return NewRequestError(ErrorCodeInvalidValue, "max_overlap", "maximum overlap %v is not in [0, 1), 0 for the default", req.MaxOverlap)
+	}
+	return nil
+}
+
+// Replace the zero values of the request by their defaults
+func (req AlternativesRequest) withDefaults() AlternativesRequest {
+	if req.Alternatives == 0 {
+		req.Alternatives = DefaultAlternatives
+	}
+	if req.MaxStretch == 0 {
+		req.MaxStretch = DefaultMaxStretch
+	}
+	if req.MaxOverlap == 0 {
+		req.MaxOverlap = DefaultMaxOverlap
+	}
+	return req
+}
+
+// ProcessAlternativesRequest computes the best route and up to k-1 alternative routes by the penalty method:
+// After each search, the weights of the edges of the found path are multiplied by a penalty, such that the next search prefers other edges.
+// A path is accepted iff its length does not exceed the maximum stretch and it shares at most the maximum overlap of its length with every accepted route.
+// Since penalties only increase weights, the ALT heuristic remains valid, whereas routers relying on arc flags are replaced as for avoid areas.
+func (sr ShipRouter1[N, E]) ProcessAlternativesRequest(ctx context.Context, req AlternativesRequest) (AlternativesResponse, error) {
+	if err := req.Validate(); err != nil {
+		return AlternativesResponse{}, err
+	}
+	req = req.withDefaults()
+	startTime := time.Now()
+
+	stops := make([]g.NodeId, 2)
+	for i, p := range []Point{req.Origin, req.Destination} {
+		nodeId, _, err := sr.snap(p, stopField(i, 2))
+		if err != nil {
+			return AlternativesResponse{}, err
+		}
+		stops[i] = nodeId
+	}
+
+	newRouter := sr.NewRouter
+	if sr.NewRestrictedRouter != nil {
+		newRouter = sr.NewRestrictedRouter
+	}
+	penalized := penalizedGraph[N, E]{Graph: sr.Graph, penalties: make(map[[2]g.NodeId]float64)}
+	router := newRouter(newCancellableGraph[N, E](ctx, penalized))
+
+	accepted := make([][]g.NodeId, 0, req.Alternatives)
+	lengths := make([]int, 0, req.Alternatives)
+	// the penalties may route subsequent searches along the same paths again, so the number of searches is bounded
+	for search := 0; search < 4*req.Alternatives && len(accepted) < req.Alternatives; search++ {
+		res := router.Route(stops[0], stops[1], false)
+		if err := ctx.Err(); err != nil {
+			return AlternativesResponse{}, err
+		}
+		if res.Length < 0 {
+			sr.Metrics.ObserveUnreachable(sr.Id)
+			return AlternativesResponse{}, NewRequestError(ErrorCodeUnreachable, "destination", "no route from origin to destination exists")
+		}
+		length := sr.pathLength(res.Path)
+		accept := len(accepted) == 0 || float64(length) <= req.MaxStretch*float64(lengths[0])
+		for i := 0; accept && i < len(accepted); i++ {
+			accept = sr.overlap(res.Path, accepted[i], length) <= req.MaxOverlap
+		}
+		if accept {
+			accepted = append(accepted, res.Path)
+			lengths = append(lengths, length)
+		}
+		penalized.penalize(res.Path)
+	}
+
+	routes := make([]AlternativeRoute, 0, len(accepted))
+	for i, path := range accepted {
+		waypoints := make([]Point, 0, len(path))
+		for _, nodeId := range path {
+			waypoints = append(waypoints, getPoint(sr.Graph.GetNode(nodeId)))
+		}
+		route := AlternativeRoute{Path: Path{Waypoints: waypoints, Length: lengths[i]}, Stretch: 1, Overlap: 1}
+		if lengths[0] > 0 {
+			route.Stretch = float64(lengths[i]) / float64(lengths[0])
+		}
+		if i > 0 {
+			route.Overlap = sr.overlap(path, accepted[0], lengths[i])
+		}
+		routes = append(routes, route)
+	}
+	sort.SliceStable(routes[1:], func(i, j int) bool {
+		return routes[i+1].Path.Length < routes[j+1].Path.Length
+	})
+	return AlternativesResponse{Routes: routes, Time: time.Since(startTime).Milliseconds()}, nil
+}
+
+// Fraction of the length of the path, which it shares with the other path
+func (sr ShipRouter1[N, E]) overlap(path, other []g.NodeId, length int) float64 {
+	if length == 0 {
+		return 1
+	}
+	edges := make(map[[2]g.NodeId]bool, len(other))
+	for i := 1; i < len(other); i++ {
+		edges[[2]g.NodeId{other[i-1], other[i]}] = true
+	}
+	shared := 0
+	for i := 1; i < len(path); i++ {
+		if edges[[2]g.NodeId{path[i-1], path[i]}] {
+			shared += sr.pathLength(path[i-1 : i+1])
+		}
+	}
+	return float64(shared) / float64(length)
+}
+
+// penalizedGraph is a view of a graph, which multiplies the weights of penalized edges.
+// The underlying graph, which may be shared by concurrent requests, is not modified.
+type penalizedGraph[N IGeoPoint, E g.IWeightedHalfEdge[int]] struct {
+	g.Graph[N, E]
+	penalties map[[2]g.NodeId]float64 // keys are pairs of from and to node
+}
+
+// Multiply the weights of all edges of the path in both directions by the penalty.
+// Bidirectional routers search the reverse edges backwards on the same view, which must have the same weights as the forward edges.
+func (pg penalizedGraph[N, E]) penalize(path []g.NodeId) {
+	for i := 1; i < len(path); i++ {
+		for _, edge := range [][2]g.NodeId{{path[i-1], path[i]}, {path[i], path[i-1]}} {
+			if penalty, ok := pg.penalties[edge]; ok {
+				pg.penalties[edge] = penalty * alternativePenalty
+			} else {
+				pg.penalties[edge] = alternativePenalty
+			}
+		}
+	}
+}
+
+func (pg penalizedGraph[N, E]) GetHalfEdgesFrom(id g.NodeId) []E {
+	edges := pg.Graph.GetHalfEdgesFrom(id)
+	var penalized []E
+	for i, edge := range edges {
+		if penalty, ok := pg.penalties[[2]g.NodeId{id, edge.To()}]; ok {
+			if penalized == nil {
+				// copy the edges to leave the underlying graph untouched
+				penalized = make([]E, len(edges))
+				copy(penalized, edges)
+			}
+			penalized[i] = withWeight(edge, int(math.Round(float64(edge.Weight())*penalty)))
+		}
+	}
+	if penalized == nil {
+		return edges
+	}
+	return penalized
+}
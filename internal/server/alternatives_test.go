@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	sp "github.com/dmholtz/graffiti/algorithms/shortest_path"
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+func TestProcessAlternativesRequest(t *testing.T) {
+	graph := gridGraph(5, 7)
+	sr := newTestShipRouter(graph)
+	origin, destination := Point{Lat: 2, Lon: 0}, Point{Lat: 2, Lon: 6}
+	best := mustRoute(t, sr, RouteRequest{Origin: origin, Destination: destination})
+
+	req := AlternativesRequest{Origin: origin, Destination: destination, Alternatives: 3, MaxStretch: 1.5, MaxOverlap: 0.5}
+	res, err := sr.ProcessAlternativesRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Routes) < 2 || len(res.Routes) > 3 {
+		t.Fatalf("Expected 2 or 3 routes, got %d", len(res.Routes))
+	}
+	if first := res.Routes[0]; first.Path.Length != best.Path.Length || first.Stretch != 1 || first.Overlap != 1 {
+		t.Errorf("The first route should be the best route, got length %d, stretch %v and overlap %v", first.Path.Length, first.Stretch, first.Overlap)
+	}
+	for i, route := range res.Routes[1:] {
+		if route.Stretch < 1 || route.Stretch > req.MaxStretch || route.Overlap > req.MaxOverlap {
+			t.Errorf("Alternative %d has stretch %v and overlap %v", i+1, route.Stretch, route.Overlap)
+		}
+		if route.Path.Length < res.Routes[i].Path.Length {
+			t.Errorf("Alternatives are not ordered by length")
+		}
+		if route.Path.Waypoints[0] != origin || route.Path.Waypoints[len(route.Path.Waypoints)-1] != destination {
+			t.Errorf("Alternative %d does not connect origin and destination", i+1)
+		}
+		for j := 0; j <= i; j++ {
+			if reflect.DeepEqual(route.Path.Waypoints, res.Routes[j].Path.Waypoints) {
+				t.Errorf("Alternatives %d and %d are identical", i+1, j)
+			}
+		}
+	}
+
+	// the best route is not affected by the penalties
+	if again := mustRoute(t, sr, RouteRequest{Origin: origin, Destination: destination}); again.Path.Length != best.Path.Length {
+		t.Errorf("The shared graph must not be modified")
+	}
+
+	req.Alternatives = 1
+	if res, err := sr.ProcessAlternativesRequest(context.Background(), req); err != nil || len(res.Routes) != 1 {
+		t.Errorf("Expected only the best route, got %v", res.Routes)
+	}
+}
+
+func TestProcessAlternativesRequestBidirectional(t *testing.T) {
+	graph := gridGraph(5, 7)
+	sr := newTestShipRouter(graph)
+	// the backward search of the bidirectional router reads the reverse edges of the same view
+	sr.NewRestrictedRouter = func(graph g.Graph[g.GeoPoint, g.WeightedHalfEdge[int]]) sp.Router[int] {
+		return sp.BiDijkstraRouter[g.GeoPoint, g.WeightedHalfEdge[int], int]{Graph: graph, Transpose: graph, MaxInitializerValue: math.MaxInt}
+	}
+	origin, destination := Point{Lat: 2, Lon: 0}, Point{Lat: 2, Lon: 6}
+	req := AlternativesRequest{Origin: origin, Destination: destination, Alternatives: 3, MaxStretch: 1.5, MaxOverlap: 0.5}
+	res, err := sr.ProcessAlternativesRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Routes) < 2 {
+		t.Fatalf("Expected alternative routes, got %d routes", len(res.Routes))
+	}
+	for i, route := range res.Routes {
+		for j := 0; j < i; j++ {
+			if reflect.DeepEqual(route.Path.Waypoints, res.Routes[j].Path.Waypoints) {
+				t.Errorf("Alternatives %d and %d are identical", i, j)
+			}
+		}
+		if i > 0 && route.Overlap > req.MaxOverlap {
+			t.Errorf("Alternative %d has overlap %v", i, route.Overlap)
+		}
+	}
+
+	penalized := penalizedGraph[g.GeoPoint, g.WeightedHalfEdge[int]]{Graph: graph, penalties: make(map[[2]g.NodeId]float64)}
+	penalized.penalize([]g.NodeId{0, 1})
+	weight := func(from, to g.NodeId) int {
+		for _, edge := range penalized.GetHalfEdgesFrom(from) {
+			if edge.To() == to {
+				return edge.Weight()
+			}
+		}
+		return -1
+	}
+	if forward, backward := weight(0, 1), weight(1, 0); forward != backward || forward <= distance(Point{Lat: 0, Lon: 0}, Point{Lat: 0, Lon: 1}) {
+		t.Errorf("Both directions of a penalized edge should have the same weight, got %d and %d", forward, backward)
+	}
+}
+
+func TestAlternativesRequestValidate(t *testing.T) {
+	if err := (AlternativesRequest{}).Validate(); err != nil {
+		t.Errorf("Default values should be valid: %v", err)
+	}
+	invalid := map[string]AlternativesRequest{
+		"alternatives": {Alternatives: MaxAlternatives + 1},
+		"max_stretch":  {MaxStretch: 0.9},
+		"max_overlap":  {MaxOverlap: 1},
+		"destination":  {Destination: Point{Lat: 91}},
+	}
+	for field, req := range invalid {
+		var reqErr *RequestError
+		if err := req.Validate(); !errors.As(err, &reqErr) || reqErr.Field != field {
+			t.Errorf("Expected error for field %s, got %v", field, err)
+		}
+	}
+}
+
+func TestProcessAlternativesRequestUnreachable(t *testing.T) {
+	alg := &g.AdjacencyListGraph[g.GeoPoint, g.WeightedHalfEdge[int]]{}
+	alg.AppendNode(g.GeoPoint{Lat: 0, Lon: 0})
+	alg.AppendNode(g.GeoPoint{Lat: 0, Lon: 1})
+	sr := newTestShipRouter(g.NewAdjacencyArrayFromGraph[g.GeoPoint, g.WeightedHalfEdge[int]](alg))
+
+	_, err := sr.ProcessAlternativesRequest(context.Background(), AlternativesRequest{Origin: Point{Lat: 0, Lon: 0}, Destination: Point{Lat: 0, Lon: 1}})
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.Code != ErrorCodeUnreachable {
+		t.Errorf("Expected unreachable error, got %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"fmt"
+
+	g "github.com/dmholtz/graffiti/graph"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+)
+
+// Area bounded by a polygon. Points in holes of the polygon are not contained in the area.
+type polygonArea struct {
+	outer geo.Polygon
+	holes []geo.Polygon
+	bbox  geo.BoundingBox
+}
+
+// Convert GeoJSON polygons and multipolygons to areas, which must not be entered by a route.
+// A *RequestError referring to the offending geometry is returned if a geometry is not a valid polygon.
+func newAvoidAreas(geometries []geojson.Geometry) ([]polygonArea, error) {
+	areas := make([]polygonArea, 0, len(geometries))
+	for i, geometry := range geometries {
+		var polygons []orb.Polygon
+		switch coordinates := geometry.Coordinates.(type) {
+		case orb.Polygon:
+			polygons = []orb.Polygon{coordinates}
+		case orb.MultiPolygon:
+			polygons = coordinates
+		default:
+			return nil, NewRequestError(ErrorCodeInvalidValue, avoidAreaField(i), "geometry of type %s is not a polygon", geometry.Type)
+		}
+		for _, polygon := range polygons {
+			area, err := newPolygonArea(polygon)
+			if err != nil {
+				return nil, NewRequestError(ErrorCodeInvalidValue, avoidAreaField(i), "%s", err)
+			}
+			areas = append(areas, area)
+		}
+	}
+	return areas, nil
+}
+
+// Convert a GeoJSON polygon, whose first ring is the outer ring and whose other rings are holes
+func newPolygonArea(polygon orb.Polygon) (polygonArea, error) {
+	if len(polygon) == 0 {
+		return polygonArea{}, fmt.Errorf("polygon has no outer ring")
+	}
+	rings := make([]geo.Polygon, 0, len(polygon))
+	for _, ring := range polygon {
+		if len(ring) < 4 || !ring.Closed() {
+			return polygonArea{}, fmt.Errorf("ring must be closed and consist of at least 4 positions")
+		}
+		points := make(geo.Polygon, 0, len(ring))
+		for _, position := range ring {
+			p := Point{Lat: position.Lat(), Lon: position.Lon()}
+			if err := p.Validate(""); err != nil {
+				return polygonArea{}, fmt.Errorf("position %v is out of range", position)
+			}
+			points = append(points, geo.NewPoint(p.Lat, p.Lon))
+		}
+		rings = append(rings, points)
+	}
+	return polygonArea{outer: rings[0], holes: rings[1:], bbox: rings[0].LatLonBoundingBox()}, nil
+}
+
+// Checks whether the point is inside the area, using the bounding box as a fast prefilter
+func (area polygonArea) contains(p *geo.Point) bool {
+	if !area.bbox.Contains(*p) || !area.outer.Contains(p) {
+		return false
+	}
+	for _, hole := range area.holes {
+		if hole.Contains(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func avoidAreaField(i int) string {
+	return fmt.Sprintf("avoid_areas[%d]", i)
+}
+
+// Nodes and edges of a graph, which are precomputed for a restriction such as a vessel profile and shared by all requests
+type restrictionSet struct {
+	blockedNodes []bool
+	blockedEdges map[[2]g.NodeId]struct{} // keys are pairs of from and to node
+	restricted   bool                     // true iff any node or edge is blocked
+}
+
+func newRestrictionSet(nodeCount int) *restrictionSet {
+	return &restrictionSet{blockedNodes: make([]bool, nodeCount), blockedEdges: make(map[[2]g.NodeId]struct{})}
+}
+
+func (rs *restrictionSet) blockNode(id g.NodeId) {
+	rs.blockedNodes[id] = true
+	rs.restricted = true
+}
+
+// Block the edge between both nodes in both directions
+func (rs *restrictionSet) blockEdge(from, to g.NodeId) {
+	rs.blockedEdges[[2]g.NodeId{from, to}] = struct{}{}
+	rs.blockedEdges[[2]g.NodeId{to, from}] = struct{}{}
+	rs.restricted = true
+}
+
+// restrictedGraph is a view of a graph, which hides all blocked nodes and edges as well as the edges from and to blocked nodes.
+// Routes are thus restricted to the other nodes without modifying the underlying graph, which may be shared by concurrent requests.
+type restrictedGraph[N IGeoPoint, E g.IWeightedHalfEdge[int]] struct {
+	g.Graph[N, E]
+	blocked      []bool
+	blockedEdges []map[[2]g.NodeId]struct{}
+}
+
+// Create a view of the graph without the nodes inside any of the areas and without the nodes and edges of the restriction sets
+func newRestrictedGraph[N IGeoPoint, E g.IWeightedHalfEdge[int]](graph g.Graph[N, E], areas []polygonArea, sets []*restrictionSet) restrictedGraph[N, E] {
+	blockedEdges := make([]map[[2]g.NodeId]struct{}, 0, len(sets))
+	for _, set := range sets {
+		if len(set.blockedEdges) > 0 {
+			blockedEdges = append(blockedEdges, set.blockedEdges)
+		}
+	}
+	if len(areas) == 0 && len(sets) == 1 {
+		// the blocked nodes of a single restriction set are shared without copying
+		return restrictedGraph[N, E]{Graph: graph, blocked: sets[0].blockedNodes, blockedEdges: blockedEdges}
+	}
+
+	blocked := make([]bool, graph.NodeCount())
+	for nodeId := range blocked {
+		for _, set := range sets {
+			if set.blockedNodes[nodeId] {
+				blocked[nodeId] = true
+				break
+			}
+		}
+		if blocked[nodeId] {
+			continue
+		}
+		p := getPoint(graph.GetNode(nodeId))
+		point := geo.NewPoint(p.Lat, p.Lon)
+		for _, area := range areas {
+			if area.contains(point) {
+				blocked[nodeId] = true
+				break
+			}
+		}
+	}
+	return restrictedGraph[N, E]{Graph: graph, blocked: blocked, blockedEdges: blockedEdges}
+}
+
+// Blocked reports whether the node is hidden by the view
+func (rg restrictedGraph[N, E]) Blocked(id g.NodeId) bool {
+	return rg.blocked[id]
+}
+
+func (rg restrictedGraph[N, E]) hidden(from g.NodeId, edge E) bool {
+	if rg.blocked[edge.To()] {
+		return true
+	}
+	for _, blockedEdges := range rg.blockedEdges {
+		if _, ok := blockedEdges[[2]g.NodeId{from, edge.To()}]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (rg restrictedGraph[N, E]) GetHalfEdgesFrom(id g.NodeId) []E {
+	if rg.blocked[id] {
+		return []E{}
+	}
+	edges := rg.Graph.GetHalfEdgesFrom(id)
+	for i, edge := range edges {
+		if rg.hidden(id, edge) {
+			// copy the edges to leave the underlying graph untouched
+			filtered := make([]E, i, len(edges))
+			copy(filtered, edges[:i])
+			for _, other := range edges[i+1:] {
+				if !rg.hidden(id, other) {
+					filtered = append(filtered, other)
+				}
+			}
+			return filtered
+		}
+	}
+	return edges
+}
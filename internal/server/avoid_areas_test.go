@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	g "github.com/dmholtz/graffiti/graph"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+)
+
+// Rectangular GeoJSON polygon
+func rectangle(latMin, lonMin, latMax, lonMax float64) orb.Polygon {
+	return orb.Polygon{orb.Ring{{lonMin, latMin}, {lonMax, latMin}, {lonMax, latMax}, {lonMin, latMax}, {lonMin, latMin}}}
+}
+
+func TestNewAvoidAreas(t *testing.T) {
+	polygon := rectangle(0, 0, 10, 10)
+	polygon = append(polygon, rectangle(4, 4, 6, 6)[0]) // hole
+	multiPolygon := orb.MultiPolygon{rectangle(20, 20, 21, 21), rectangle(30, 30, 31, 31)}
+
+	areas, err := newAvoidAreas([]geojson.Geometry{*geojson.NewGeometry(polygon), *geojson.NewGeometry(multiPolygon)})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(areas) != 3 {
+		t.Fatalf("Expected 3 areas, got %d", len(areas))
+	}
+	if !areas[0].contains(geo.NewPoint(2, 2)) || areas[0].contains(geo.NewPoint(5, 5)) || areas[0].contains(geo.NewPoint(-1, 5)) {
+		t.Errorf("Unexpected containment of area with hole")
+	}
+	if !areas[2].contains(geo.NewPoint(30.5, 30.5)) {
+		t.Errorf("Second polygon of the multipolygon should contain its center")
+	}
+
+	invalid := []geojson.Geometry{
+		*geojson.NewGeometry(orb.Point{1, 2}),
+		*geojson.NewGeometry(orb.Polygon{orb.Ring{{0, 0}, {1, 0}, {1, 1}}}),
+		*geojson.NewGeometry(rectangle(0, 0, 95, 10)),
+	}
+	for _, geometry := range invalid {
+		_, err := newAvoidAreas([]geojson.Geometry{*geojson.NewGeometry(polygon), geometry})
+		var reqErr *RequestError
+		if !errors.As(err, &reqErr) || reqErr.Field != "avoid_areas[1]" {
+			t.Errorf("Expected error for field avoid_areas[1], got %v", err)
+		}
+	}
+}
+
+func TestAvoidAreasFromJson(t *testing.T) {
+	var req RouteRequest
+	body := `{"origin": {"lat": 0, "lon": 0}, "destination": {"lat": 1, "lon": 1}, "avoid_areas": [{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}]}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(req.AvoidAreas) != 1 || req.AvoidAreas[0].Type != "Polygon" {
+		t.Errorf("Unexpected avoid areas %v", req.AvoidAreas)
+	}
+}
+
+func TestRestrictedGraph(t *testing.T) {
+	graph := gridGraph(3, 3)
+	areas, _ := newAvoidAreas([]geojson.Geometry{*geojson.NewGeometry(rectangle(0.5, 0.5, 1.5, 1.5))})
+	view := newRestrictedGraph[g.GeoPoint, g.WeightedHalfEdge[int]](graph, areas, nil)
+
+	if !view.Blocked(4) || view.Blocked(0) {
+		t.Errorf("Only the center node should be blocked")
+	}
+	if len(view.GetHalfEdgesFrom(4)) != 0 {
+		t.Errorf("Blocked nodes must not have edges")
+	}
+	if edges := view.GetHalfEdgesFrom(1); len(edges) != 2 || edges[0].To() == 4 || edges[1].To() == 4 {
+		t.Errorf("Edges to blocked nodes must be hidden, got %v", edges)
+	}
+	if edges := graph.GetHalfEdgesFrom(1); len(edges) != 3 {
+		t.Errorf("The underlying graph must not be modified, got edges %v", edges)
+	}
+}
+
+func TestProcessRequestAvoidAreas(t *testing.T) {
+	sr := newTestShipRouter(gridGraph(5, 5))
+	sr.Cache = NewRouteCache(10, 0)
+	req := RouteRequest{Origin: Point{Lat: 2, Lon: 0}, Destination: Point{Lat: 2, Lon: 4}}
+	direct := mustRoute(t, sr, req)
+
+	req.AvoidAreas = []geojson.Geometry{*geojson.NewGeometry(rectangle(0.5, 1.5, 3.5, 2.5))}
+	res := mustRoute(t, sr, req)
+	if res.Path.Length <= direct.Path.Length {
+		t.Errorf("Route of length %d does not avoid the area, direct length %d", res.Path.Length, direct.Path.Length)
+	}
+	for _, wp := range res.Path.Waypoints {
+		if wp.Lon == 2 && wp.Lat > 0.5 && wp.Lat < 3.5 {
+			t.Errorf("Waypoint %v is inside the avoid area", wp)
+		}
+	}
+	if res.CacheHit || sr.Cache.Len() != 1 {
+		t.Errorf("Routes with avoid areas must bypass the cache, cache contains %d paths", sr.Cache.Len())
+	}
+
+	// the shared graph is not modified
+	if again := mustRoute(t, sr, RouteRequest{Origin: req.Origin, Destination: req.Destination}); again.Path.Length != direct.Path.Length {
+		t.Errorf("Route without avoid areas has length %d, expected %d", again.Path.Length, direct.Path.Length)
+	}
+
+	req.Origin = Point{Lat: 2, Lon: 2}
+	_, err := sr.ProcessRequest(context.Background(), req, false)
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.Code != ErrorCodePointInAvoidArea || reqErr.Field != "origin" {
+		t.Errorf("Expected point in avoid area error for the origin, got %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"context"
+
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+// cancellableGraph is a view of a graph, which hides all edges as soon as the context is done.
+// A search on the view exhausts its priority queue quickly after the request has been cancelled.
+type cancellableGraph[N any, E g.IHalfEdge] struct {
+	g.Graph[N, E]
+	done <-chan struct{}
+}
+
+// Create a view of the graph whose edges disappear once the context is done
+func newCancellableGraph[N any, E g.IHalfEdge](ctx context.Context, graph g.Graph[N, E]) cancellableGraph[N, E] {
+	return cancellableGraph[N, E]{Graph: graph, done: ctx.Done()}
+}
+
+func (cg cancellableGraph[N, E]) GetHalfEdgesFrom(id g.NodeId) []E {
+	select {
+	case <-cg.done:
+		return make([]E, 0)
+	default:
+		return cg.Graph.GetHalfEdgesFrom(id)
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"sort"
+
+	g "github.com/dmholtz/graffiti/graph"
+
+	"github.com/dmholtz/osm-ship-routing/pkg/chokepoint"
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+)
+
+// Maximum distance in meters between a waypoint of a canal and the node, which has been injected for the waypoint.
+// The distance is positive, since the coordinates of the nodes are rounded in .fmi files.
+const canalTolerance = 500
+
+// ChokepointIndex stores the nodes and edges of a graph, which belong to each chokepoint, and the limits of each chokepoint
+type ChokepointIndex struct {
+	restrictions map[string]*restrictionSet
+	limits       map[string]Limits
+}
+
+// Create a ChokepointIndex by locating the chokepoints in the graph.
+// The nodes of a canal are the nodes closest to its waypoints, which have been injected by the graph builder. The edges between them are blocked as well.
+// A canal, which has not been injected into the graph, does not restrict the graph. The nodes of a strait are the nodes inside its polygons.
+func NewChokepointIndex[N IGeoPoint, E g.IWeightedHalfEdge[int]](graph g.Graph[N, E], index *NodeIndex, chokepoints []chokepoint.Chokepoint) (*ChokepointIndex, error) {
+	ci := &ChokepointIndex{restrictions: make(map[string]*restrictionSet), limits: make(map[string]Limits)}
+	for _, cp := range chokepoints {
+		rs := newRestrictionSet(graph.NodeCount())
+		if cp.IsCanal() {
+			nodes := make([]g.NodeId, 0, len(cp.Canal))
+			for _, waypoint := range cp.Canal {
+				p := Point{Lat: waypoint.Lat, Lon: waypoint.Lon}
+				nodeId, ok := index.Nearest(p)
+				if !ok || distance(p, getPoint(graph.GetNode(nodeId))) > canalTolerance {
+					nodes = nil
+					break
+				}
+				nodes = append(nodes, nodeId)
+			}
+			for i, nodeId := range nodes {
+				rs.blockNode(nodeId)
+				if i > 0 && hasEdge(graph, nodes[i-1], nodeId) {
+					rs.blockEdge(nodes[i-1], nodeId)
+				}
+			}
+		} else {
+			areas := make([]polygonArea, 0, len(cp.Areas))
+			for _, polygon := range cp.Areas {
+				area, err := newPolygonArea(polygon)
+				if err != nil {
+					return nil, err
+				}
+				areas = append(areas, area)
+			}
+			for nodeId := 0; nodeId < graph.NodeCount(); nodeId++ {
+				p := getPoint(graph.GetNode(nodeId))
+				point := geo.NewPoint(p.Lat, p.Lon)
+				for _, area := range areas {
+					if area.contains(point) {
+						rs.blockNode(nodeId)
+						break
+					}
+				}
+			}
+		}
+		ci.restrictions[cp.Id] = rs
+		ci.limits[cp.Id] = Limits{Depth: cp.Depth, AirDraft: cp.AirDraft, Beam: cp.Beam}
+	}
+	return ci, nil
+}
+
+// Restrictions returns the limits of the chokepoints as restrictions of their nodes, which restrict the vessel profiles.
+// Since the edges from and to restricted nodes cannot be used either, the edges of a canal need not be restricted.
+func (ci *ChokepointIndex) Restrictions() Restrictions {
+	restrictions := Restrictions{Nodes: make([]NodeRestriction, 0), Edges: make([]EdgeRestriction, 0)}
+	for _, id := range ci.Ids() {
+		limits := ci.limits[id]
+		if limits == (Limits{}) {
+			continue
+		}
+		for nodeId, blocked := range ci.restrictions[id].blockedNodes {
+			if blocked {
+				restrictions.Nodes = append(restrictions.Nodes, NodeRestriction{Id: nodeId, Limits: limits})
+			}
+		}
+	}
+	return restrictions
+}
+
+// Contains reports whether the chokepoint is known and has been located in the graph
+func (ci *ChokepointIndex) Contains(id string) bool {
+	rs, ok := ci.restrictions[id]
+	return ok && rs.restricted
+}
+
+// Ids returns the ids of all chokepoints in alphabetical order
+func (ci *ChokepointIndex) Ids() []string {
+	ids := make([]string, 0, len(ci.restrictions))
+	for id := range ci.restrictions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	g "github.com/dmholtz/graffiti/graph"
+	"github.com/paulmach/orb"
+
+	"github.com/dmholtz/osm-ship-routing/pkg/chokepoint"
+	gr "github.com/dmholtz/osm-ship-routing/pkg/graph"
+)
+
+// Chokepoints of a grid graph with 5 rows and columns: a canal along the nodes of row 2 between column 1 and 3
+// and a strait covering column 2 between row 1 and 3
+var testChokepoints = []chokepoint.Chokepoint{
+	{Id: "canal", Canal: []gr.Node{{Lat: 2, Lon: 1}, {Lat: 2, Lon: 2}, {Lat: 2, Lon: 3}}},
+	{Id: "strait", Areas: []orb.Polygon{rectangle(0.5, 1.5, 3.5, 2.5)}},
+	{Id: "missing", Canal: []gr.Node{{Lat: 2, Lon: 1}, {Lat: 2.5, Lon: 1.5}}},
+}
+
+func TestNewChokepointIndex(t *testing.T) {
+	graph := gridGraph(5, 5)
+	chokepoints, err := NewChokepointIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph, NewNodeIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph), testChokepoints)
+	if err != nil {
+		t.Fatalf("NewChokepointIndex failed: %v", err)
+	}
+	if ids := chokepoints.Ids(); len(ids) != 3 || ids[0] != "canal" {
+		t.Errorf("Unexpected ids %v", ids)
+	}
+	if !chokepoints.Contains("canal") || !chokepoints.Contains("strait") || chokepoints.Contains("missing") || chokepoints.Contains("suez") {
+		t.Errorf("Only the canal and the strait should be located in the graph")
+	}
+	canal := chokepoints.restrictions["canal"]
+	if !canal.blockedNodes[2*5+1] || !canal.blockedNodes[2*5+3] || canal.blockedNodes[2*5+4] {
+		t.Errorf("Unexpected blocked nodes of the canal")
+	}
+	if _, ok := canal.blockedEdges[[2]g.NodeId{2*5 + 2, 2*5 + 1}]; !ok || len(canal.blockedEdges) != 4 {
+		t.Errorf("The edges between the waypoints should be blocked, got %v", canal.blockedEdges)
+	}
+	strait := chokepoints.restrictions["strait"]
+	if !strait.blockedNodes[1*5+2] || !strait.blockedNodes[3*5+2] || strait.blockedNodes[2*5+1] {
+		t.Errorf("Unexpected blocked nodes of the strait")
+	}
+}
+
+func TestProcessRequestAvoidChokepoints(t *testing.T) {
+	graph := gridGraph(5, 5)
+	sr := newTestShipRouter(graph)
+	sr.Cache = NewRouteCache(10, 0)
+	chokepoints, err := NewChokepointIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph, sr.Index, testChokepoints)
+	if err != nil {
+		t.Fatalf("NewChokepointIndex failed: %v", err)
+	}
+	sr.Chokepoints = chokepoints
+	req := RouteRequest{Origin: Point{Lat: 2, Lon: 0}, Destination: Point{Lat: 2, Lon: 4}}
+	direct := mustRoute(t, sr, req)
+
+	// avoiding a chokepoint, which is not contained in the graph, would have no effect
+	req.Avoid = []string{"canal", "missing"}
+	var reqErr *RequestError
+	if _, err := sr.ProcessRequest(context.Background(), req, false); !errors.As(err, &reqErr) || reqErr.Code != ErrorCodeInvalidValue || reqErr.Field != "avoid[1]" {
+		t.Errorf("Expected invalid value error for field avoid[1], got %v", err)
+	}
+
+	req.Avoid = []string{"strait", "canal"}
+	res := mustRoute(t, sr, req)
+	if res.Path.Length <= direct.Path.Length || res.CacheHit {
+		t.Errorf("Route of length %d does not avoid the chokepoints, direct length %d", res.Path.Length, direct.Path.Length)
+	}
+	for _, wp := range res.Path.Waypoints {
+		if wp.Lon == 2 && wp.Lat > 0.5 && wp.Lat < 3.5 {
+			t.Errorf("Waypoint %v is inside the strait", wp)
+		}
+	}
+	// the order of the chokepoints does not matter for the cache
+	req.Avoid = []string{"canal", "strait"}
+	if again := mustRoute(t, sr, req); !again.CacheHit || again.Path.Length != res.Path.Length {
+		t.Errorf("Routes avoiding the same chokepoints should be cached")
+	}
+
+	req.Avoid = []string{"strait", "suez"}
+	_, err = sr.ProcessRequest(context.Background(), req, false)
+	if !errors.As(err, &reqErr) || reqErr.Code != ErrorCodeInvalidValue || reqErr.Field != "avoid[1]" {
+		t.Errorf("Expected invalid value error for field avoid[1], got %v", err)
+	}
+
+	req.Avoid = []string{"canal"}
+	req.Origin = Point{Lat: 2, Lon: 2}
+	if _, err := sr.ProcessRequest(context.Background(), req, false); !errors.As(err, &reqErr) || reqErr.Code != ErrorCodePointInAvoidArea || reqErr.Field != "origin" {
+		t.Errorf("Expected point in avoid area error for the origin, got %v", err)
+	}
+}
+
+func TestProcessRequestChokepointLimits(t *testing.T) {
+	graph := gridGraph(5, 5)
+	sr := newTestShipRouter(graph)
+	// the strait is too shallow for the vlcc, the canal has no limits
+	limited := []chokepoint.Chokepoint{testChokepoints[0], testChokepoints[1]}
+	limited[1].Depth = 15
+	chokepoints, err := NewChokepointIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph, sr.Index, limited)
+	if err != nil {
+		t.Fatalf("NewChokepointIndex failed: %v", err)
+	}
+	restrictions := chokepoints.Restrictions()
+	if len(restrictions.Nodes) != 3 || restrictions.Nodes[0].Depth != 15 {
+		t.Fatalf("Expected the three nodes of the strait to be restricted, got %+v", restrictions)
+	}
+	if sr.Vessels, err = NewVesselIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph, testVessels, restrictions); err != nil {
+		t.Fatalf("NewVesselIndex failed: %v", err)
+	}
+	req := RouteRequest{Origin: Point{Lat: 2, Lon: 0}, Destination: Point{Lat: 2, Lon: 4}}
+	direct := mustRoute(t, sr, req)
+
+	req.Vessel = "feeder"
+	if feeder := mustRoute(t, sr, req); feeder.Path.Length != direct.Path.Length {
+		t.Errorf("The feeder should pass the strait, got length %d and direct length %d", feeder.Path.Length, direct.Path.Length)
+	}
+	req.Vessel = "vlcc"
+	vlcc := mustRoute(t, sr, req)
+	for _, wp := range vlcc.Path.Waypoints {
+		if wp.Lon == 2 && wp.Lat >= 1 && wp.Lat <= 3 {
+			t.Errorf("Route of the vlcc passes the shallow strait at %v", wp)
+		}
+	}
+	if vlcc.Path.Length <= direct.Path.Length {
+		t.Errorf("The vlcc should be routed around the strait, got length %d and direct length %d", vlcc.Path.Length, direct.Path.Length)
+	}
+}
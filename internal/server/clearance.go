@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"math"
+
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+)
+
+// Defaults and limits of the distance to the coast
+const (
+	DefaultSafetyMargin = 3 * metersPerNauticalMile // minimum distance of each leg to the coast, unit meters
+	// maximum distance to the coast, unit meters. Coastlines being farther away are not searched for.
+	MaxCoastDistance = 1000000
+)
+
+// Distance of the waypoints of a path to the coast and the legs of the path, which come closer to the coast than the safety margin
+type Clearance struct {
+	SafetyMargin int `json:"safety_margin"` // unit meters
+	// distance of each waypoint to the nearest coastline, unit meters. -1 iff no coastline is within MaxCoastDistance.
+	Waypoints  []int                `json:"waypoints"`
+	Violations []ClearanceViolation `json:"violations"`
+}
+
+// A leg of the path from the waypoint with the same index to the next waypoint, which comes closer to the coast than the safety margin
+type ClearanceViolation struct {
+	Leg      int `json:"leg"`
+	Distance int `json:"distance"` // minimum distance of the leg to the coast, unit meters
+}
+
+// Safety margin of the request, which defaults to the safety margin of the ship router
+func (sr ShipRouter1[N, E]) safetyMargin(req RouteRequest) int {
+	if req.SafetyMargin > 0 {
+		return req.SafetyMargin
+	}
+	if sr.SafetyMargin > 0 {
+		return sr.SafetyMargin
+	}
+	return DefaultSafetyMargin
+}
+
+// Compute the distance of each waypoint to the nearest coastline and the legs between consecutive waypoints, which come closer to the coast than the safety margin (unit meters)
+func (sr ShipRouter1[N, E]) clearance(ctx context.Context, waypoints []Point, safetyMargin int) (*Clearance, error) {
+	clearance := &Clearance{SafetyMargin: safetyMargin, Waypoints: make([]int, 0, len(waypoints)), Violations: make([]ClearanceViolation, 0)}
+	for _, wp := range waypoints {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		distance := -1
+		if d, ok := sr.Coastlines.Distance(geo.NewPoint(wp.Lat, wp.Lon), MaxCoastDistance); ok {
+			distance = int(math.Round(d))
+		}
+		clearance.Waypoints = append(clearance.Waypoints, distance)
+	}
+	for i := 1; i < len(waypoints); i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		a, b := geo.NewPoint(waypoints[i-1].Lat, waypoints[i-1].Lon), geo.NewPoint(waypoints[i].Lat, waypoints[i].Lon)
+		// coastlines beyond the safety margin are irrelevant
+		if d, ok := sr.Coastlines.ArcDistance(a, b, float64(safetyMargin)); ok && d < float64(safetyMargin) {
+			clearance.Violations = append(clearance.Violations, ClearanceViolation{Leg: i - 1, Distance: int(math.Round(d))})
+		}
+	}
+	return clearance, nil
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+)
+
+func TestProcessRequestClearance(t *testing.T) {
+	sr := newTestShipRouter(gridGraph(5, 5))
+	sr.Coastlines = islandIndex()
+	island := geo.Polygon{geo.NewPoint(2.2, 2.2), geo.NewPoint(2.2, 2.8), geo.NewPoint(2.8, 2.8), geo.NewPoint(2.8, 2.2), geo.NewPoint(2.2, 2.2)}
+	// the route passes the island at a distance of 0.2 degrees, which is about 22 km
+	req := RouteRequest{Origin: Point{Lat: 2, Lon: 0}, Destination: Point{Lat: 2, Lon: 4}, CoastDistance: true}
+
+	res := mustRoute(t, sr, req)
+	if res.Clearance == nil || res.Clearance.SafetyMargin != DefaultSafetyMargin || len(res.Clearance.Violations) != 0 {
+		t.Fatalf("Expected no violations of the default safety margin, got %+v", res.Clearance)
+	}
+	if len(res.Clearance.Waypoints) != len(res.Path.Waypoints) {
+		t.Fatalf("Expected a distance for each of the %d waypoints, got %v", len(res.Path.Waypoints), res.Clearance.Waypoints)
+	}
+	for i, wp := range res.Path.Waypoints {
+		want := island.BoundaryDistance(geo.NewPoint(wp.Lat, wp.Lon))
+		if math.Abs(float64(res.Clearance.Waypoints[i])-want) > 1 {
+			t.Errorf("Distance of waypoint %d is %d m, expected %v m", i, res.Clearance.Waypoints[i], want)
+		}
+	}
+
+	req.SafetyMargin = 25000
+	res = mustRoute(t, sr, req)
+	if violations := res.Clearance.Violations; len(violations) != 1 || violations[0].Leg != 2 || violations[0].Distance > 22300 || violations[0].Distance < 22000 {
+		t.Errorf("Expected the leg south of the island to violate the safety margin, got %+v", violations)
+	}
+	req.SafetyMargin = 40000
+	if res = mustRoute(t, sr, req); len(res.Clearance.Violations) != 3 {
+		t.Errorf("Expected the three legs next to the island to violate the safety margin, got %+v", res.Clearance.Violations)
+	}
+
+	req.CoastDistance = false
+	if res = mustRoute(t, sr, req); res.Clearance != nil {
+		t.Errorf("Clearance should be omitted unless requested")
+	}
+}
+
+func TestClearanceRequestErrors(t *testing.T) {
+	sr := newTestShipRouter(gridGraph(3, 3))
+	req := RouteRequest{Origin: Point{Lat: 0, Lon: 0}, Destination: Point{Lat: 2, Lon: 2}, CoastDistance: true}
+	var reqErr *RequestError
+	if _, err := sr.ProcessRequest(context.Background(), req, false); !errors.As(err, &reqErr) || reqErr.Field != "coast_distance" {
+		t.Errorf("Expected invalid value error for field coast_distance without coastlines, got %v", err)
+	}
+	sr.Coastlines = islandIndex()
+	req.SafetyMargin = -1
+	if _, err := sr.ProcessRequest(context.Background(), req, false); !errors.As(err, &reqErr) || reqErr.Field != "safety_margin" {
+		t.Errorf("Expected invalid value error for field safety_margin, got %v", err)
+	}
+}
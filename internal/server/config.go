@@ -0,0 +1,246 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Router types that can be declared in the server configuration.
+// The type of a router coincides with the default id of the router.
+const (
+	DijkstraRouterType        = "dijkstra"
+	BiDijkstraRouterType      = "bidirectional-dijkstra"
+	ArcFlagRouterType         = "arcflag-dijkstra"
+	BiArcFlagRouterType       = "bidirectional-arcflag-dijkstra"
+	TwoLevelArcFlagRouterType = "two-level-arcflag-dijkstra"
+	AltRouterType             = "a-star"
+	ArcFlagAltRouterType      = "a-star-with-bidirectional-arc-flags"
+	ThetaStarRouterType       = "theta-star" // any-angle router, requires the grid of the graph
+)
+
+// Grid types, from which graphs are built by the graph builder
+const (
+	EquiSphereGridType   = "equi-sphere-grid"
+	SimpleSphereGridType = "simple-sphere-grid"
+)
+
+// Server configuration
+type Config struct {
+	Address         string   `json:"address"`          // listen address of the HTTP server
+	CorsOrigins     []string `json:"cors_origins"`     // allowed origins for cross-origin requests, "*" allows any origin
+	Landmarks       int      `json:"landmarks"`        // number of landmarks of the ALT heuristic
+	RequestTimeout  int      `json:"request_timeout"`  // maximum processing time of a request in seconds, zero disables the timeout
+	ShutdownTimeout int      `json:"shutdown_timeout"` // time in seconds to drain in-flight requests on shutdown before their processing is aborted
+	CacheSize       int      `json:"cache_size"`       // maximum number of shortest paths in the route cache, zero disables the cache
+	CacheTTL        int      `json:"cache_ttl"`        // time in seconds until a cached shortest path expires, zero disables expiry
+	// .poly.json file of the coastline polygons for path smoothing, empty disables path smoothing
+	Coastlines string `json:"coastlines,omitempty"`
+	// minimum distance in meters of each leg of a route to the coast, whose violations are reported on request
+	SafetyMargin int `json:"safety_margin"`
+	// GeoJSON file of the penalty zones with the properties id, set and multiplier, empty disables penalty zones
+	Zones string `json:"zones,omitempty"`
+	// GeoJSON file of the chokepoints with the properties id and name, which can be avoided per request. Empty disables chokepoints.
+	Chokepoints string `json:"chokepoints,omitempty"`
+	// vessel profiles, which can be selected per request
+	Vessels []VesselProfile `json:"vessels"`
+	Graphs  []GraphConfig   `json:"graphs"`
+}
+
+// Declares a graph and the routers operating on that graph
+type GraphConfig struct {
+	File       string `json:"file"`        // .fmi file
+	NodeParser string `json:"node_parser"` // name of the node parsing function of graffiti's io package, e.g. ParsePartGeoPoint
+	EdgeParser string `json:"edge_parser"` // name of the edge parsing function of graffiti's io package, e.g. ParseLargeFlaggedHalfEdge
+	// maximum distance in meters between a requested point and its closest node, zero disables the limit.
+	// Points being farther away are considered to be on land.
+	MaxSnapDistance int         `json:"max_snap_distance"`
+	Grid            *GridConfig `json:"grid,omitempty"` // optional, required by routers that check the land / water data of the grid
+	// optional JSON file of the depth, air draft and beam limits of nodes and edges, which restrict the vessel profiles
+	Restrictions string         `json:"restrictions,omitempty"`
+	Routers      []RouterConfig `json:"routers"`
+}
+
+// Parameters of the grid, from which the graph has been built
+type GridConfig struct {
+	Type    string `json:"type"`
+	NTarget int    `json:"n_target,omitempty"` // approximate number of points of an equi sphere grid
+	NLon    int    `json:"n_lon,omitempty"`    // number of points along the longitude axis of a simple sphere grid
+	NLat    int    `json:"n_lat,omitempty"`    // number of points along the latitude axis of a simple sphere grid
+}
+
+// Validate checks the type and the parameters of the grid
+func (gc GridConfig) Validate() error {
+	switch gc.Type {
+	case EquiSphereGridType:
+		if gc.NTarget < 2 {
+			return fmt.Errorf("number of points of an equi sphere grid must be at least 2, got %d", gc.NTarget)
+		}
+	case SimpleSphereGridType:
+		if gc.NLon < 1 || gc.NLat < 2 {
+			return fmt.Errorf("simple sphere grid requires at least 1 x 2 points, got %d x %d", gc.NLon, gc.NLat)
+		}
+	default:
+		return fmt.Errorf("unknown grid type %s", gc.Type)
+	}
+	return nil
+}
+
+type RouterConfig struct {
+	Type string `json:"type"`
+	Id   string `json:"id,omitempty"` // defaults to the type of the router
+}
+
+// RouterId returns the id under which the router is exposed by the server
+func (rc RouterConfig) RouterId() string {
+	if rc.Id != "" {
+		return rc.Id
+	}
+	return rc.Type
+}
+
+// DefaultConfig returns the configuration of the public routing service
+func DefaultConfig() Config {
+	return Config{
+		Address:         ":8081",
+		CorsOrigins:     []string{"*"},
+		Landmarks:       16,
+		RequestTimeout:  60,
+		ShutdownTimeout: 30,
+		CacheSize:       10000,
+		CacheTTL:        3600,
+		SafetyMargin:    DefaultSafetyMargin,
+		Chokepoints:     "chokepoints.geojson",
+		Vessels: []VesselProfile{
+			{Name: "feeder", Draft: 9, AirDraft: 35, Beam: 25, DesignSpeed: 17, DesignFuel: 30},
+			{Name: "panamax", Draft: 12, AirDraft: 57, Beam: 32, DesignSpeed: 20, DesignFuel: 80},
+			{Name: "suezmax", Draft: 17, AirDraft: 60, Beam: 50, DesignSpeed: 15, DesignFuel: 55},
+			{Name: "vlcc", Draft: 22, AirDraft: 65, Beam: 60, DesignSpeed: 15, DesignFuel: 70},
+		},
+		Graphs: []GraphConfig{
+			{
+				File:            "graphs/ocean_equi_4_grid_arcflags128.fmi",
+				NodeParser:      "ParsePartGeoPoint",
+				EdgeParser:      "ParseLargeFlaggedHalfEdge",
+				MaxSnapDistance: 100000,
+				Grid:            &GridConfig{Type: EquiSphereGridType, NTarget: 1000000},
+				Routers: []RouterConfig{
+					{Type: DijkstraRouterType},
+					{Type: BiDijkstraRouterType},
+					{Type: ArcFlagRouterType},
+					{Type: BiArcFlagRouterType},
+					{Type: AltRouterType},
+					{Type: ArcFlagAltRouterType},
+					{Type: ThetaStarRouterType},
+				},
+			},
+			{
+				File:            "graphs/ocean_equi_4_grid_arcflags32_32.fmi",
+				NodeParser:      "Parse2LPartGeoPoint",
+				EdgeParser:      "Parse2LFlaggedHalfEdge",
+				MaxSnapDistance: 100000,
+				Routers: []RouterConfig{
+					{Type: TwoLevelArcFlagRouterType},
+				},
+			},
+		},
+	}
+}
+
+// LoadConfig reads a JSON configuration file.
+// Settings that are not contained in the file keep the values of DefaultConfig.
+func LoadConfig(filename string) (Config, error) {
+	config := DefaultConfig()
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return config, err
+	}
+	// graphs are not merged with the default graphs
+	config.Graphs = nil
+	if err := json.Unmarshal(bytes, &config); err != nil {
+		return config, fmt.Errorf("invalid config file %s: %w", filename, err)
+	}
+	return config, nil
+}
+
+// Validate checks the configuration for missing values and duplicate router ids.
+// Whether the node and edge parsers are compatible with the routers is checked when the graphs are loaded.
+func (c Config) Validate() error {
+	if c.Address == "" {
+		return fmt.Errorf("listen address is missing")
+	}
+	if c.Landmarks < 1 {
+		return fmt.Errorf("number of landmarks must be positive, got %d", c.Landmarks)
+	}
+	if c.RequestTimeout < 0 {
+		return fmt.Errorf("request timeout must not be negative, got %d", c.RequestTimeout)
+	}
+	if c.ShutdownTimeout < 0 {
+		return fmt.Errorf("shutdown timeout must not be negative, got %d", c.ShutdownTimeout)
+	}
+	if c.CacheSize < 0 {
+		return fmt.Errorf("cache size must not be negative, got %d", c.CacheSize)
+	}
+	if c.CacheTTL < 0 {
+		return fmt.Errorf("cache TTL must not be negative, got %d", c.CacheTTL)
+	}
+	if c.SafetyMargin < 0 {
+		return fmt.Errorf("safety margin must not be negative, got %d", c.SafetyMargin)
+	}
+	vesselNames := make(map[string]bool)
+	for _, vessel := range c.Vessels {
+		if vessel.Name == "" {
+			return fmt.Errorf("name of vessel profile is missing")
+		}
+		if vesselNames[vessel.Name] {
+			return fmt.Errorf("duplicate vessel profile %s", vessel.Name)
+		}
+		if vessel.Draft < 0 || vessel.AirDraft < 0 || vessel.Beam < 0 {
+			return fmt.Errorf("dimensions of vessel profile %s must not be negative", vessel.Name)
+		}
+		if vessel.DesignSpeed < 0 || vessel.DesignFuel < 0 || vessel.CO2Factor < 0 {
+			return fmt.Errorf("consumption coefficients of vessel profile %s must not be negative", vessel.Name)
+		}
+		vesselNames[vessel.Name] = true
+	}
+	if len(c.Graphs) == 0 {
+		return fmt.Errorf("no graph configured")
+	}
+	routerIds := make(map[string]bool)
+	for _, graph := range c.Graphs {
+		if graph.File == "" {
+			return fmt.Errorf("graph file is missing")
+		}
+		if graph.MaxSnapDistance < 0 {
+			return fmt.Errorf("maximum snapping distance of graph %s must not be negative", graph.File)
+		}
+		if graph.Grid != nil {
+			if err := graph.Grid.Validate(); err != nil {
+				return fmt.Errorf("grid of graph %s: %w", graph.File, err)
+			}
+		}
+		if len(graph.Routers) == 0 {
+			return fmt.Errorf("no router configured for graph %s", graph.File)
+		}
+		for _, router := range graph.Routers {
+			if routerIds[router.RouterId()] {
+				return fmt.Errorf("duplicate router id %s", router.RouterId())
+			}
+			if router.Type == ThetaStarRouterType && graph.Grid == nil {
+				return fmt.Errorf("router type %s requires the grid of graph %s", router.Type, graph.File)
+			}
+			routerIds[router.RouterId()] = true
+		}
+	}
+	return nil
+}
+
+// AllowsOrigin reports whether cross-origin requests from origin are allowed
+func (c Config) AllowsOrigin(origin string) bool {
+	for _, allowed := range c.CorsOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("Default configuration is invalid: %s", err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	content := `{
+		"address": ":9000",
+		"graphs": [{"file": "a.fmi", "node_parser": "ParseGeoPoint", "edge_parser": "ParseWeightedHalfEdge", "routers": [{"type": "dijkstra", "id": "coarse-dijkstra"}]}]
+	}`
+	filename := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Address != ":9000" {
+		t.Errorf("Unexpected address %s", config.Address)
+	}
+	// values missing in the file are taken from the default configuration
+	if config.Landmarks != DefaultConfig().Landmarks {
+		t.Errorf("Unexpected number of landmarks %d", config.Landmarks)
+	}
+	if len(config.Graphs) != 1 || config.Graphs[0].Routers[0].RouterId() != "coarse-dijkstra" {
+		t.Errorf("Unexpected graphs %+v", config.Graphs)
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Configuration should be valid: %s", err)
+	}
+}
+
+func TestConfigDuplicateRouterIds(t *testing.T) {
+	config := DefaultConfig()
+	config.Graphs[1].Routers = append(config.Graphs[1].Routers, RouterConfig{Type: DijkstraRouterType})
+	if err := config.Validate(); err == nil {
+		t.Errorf("Duplicate router ids should be rejected")
+	}
+}
+
+func TestConfigThetaStarRequiresGrid(t *testing.T) {
+	config := DefaultConfig()
+	config.Graphs[0].Grid = nil
+	if err := config.Validate(); err == nil {
+		t.Errorf("Theta-star router without grid should be rejected")
+	}
+	config.Graphs[0].Grid = &GridConfig{Type: EquiSphereGridType}
+	if err := config.Validate(); err == nil {
+		t.Errorf("Grid without number of points should be rejected")
+	}
+	config.Graphs[0].Grid.NTarget = 1000000
+	if err := config.Validate(); err != nil {
+		t.Errorf("Configuration should be valid: %s", err)
+	}
+}
+
+func TestConfigVessels(t *testing.T) {
+	config := DefaultConfig()
+	config.Vessels = append(config.Vessels, VesselProfile{Name: "vlcc", Draft: 20})
+	if err := config.Validate(); err == nil {
+		t.Errorf("Duplicate vessel profiles should be rejected")
+	}
+	config.Vessels[len(config.Vessels)-1] = VesselProfile{Name: "tug", Draft: -1}
+	if err := config.Validate(); err == nil {
+		t.Errorf("Negative draft should be rejected")
+	}
+}
+
+func TestAllowsOrigin(t *testing.T) {
+	config := Config{CorsOrigins: []string{"https://example.org"}}
+	if !config.AllowsOrigin("https://example.org") || config.AllowsOrigin("https://example.com") {
+		t.Errorf("Unexpected result of AllowsOrigin")
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import "math"
+
+// Tonnes of CO2 emitted per tonne of heavy fuel oil according to the IMO
+const DefaultCO2Factor = 3.114
+
+// Objectives of route requests
+const (
+	OptimizeDistance = "distance"
+	OptimizeFuel     = "fuel"
+)
+
+// ConsumptionModel estimates the fuel consumption of a vessel
+type ConsumptionModel interface {
+	// Fuel consumed by the vessel to travel the distance (unit meters) at the speed through water (unit knots), unit tonnes
+	Fuel(vessel VesselProfile, distance float64, speed float64) float64
+}
+
+// CubicLaw is the consumption model, whose fuel consumption per day grows with the cube of the speed.
+// It is calibrated by the design speed and the daily consumption at the design speed of each vessel.
+type CubicLaw struct{}
+
+func (CubicLaw) Fuel(vessel VesselProfile, distance float64, speed float64) float64 {
+	if vessel.DesignSpeed <= 0 || speed <= 0 {
+		return 0
+	}
+	days := distance / metersPerNauticalMile / speed / 24
+	return vessel.DesignFuel * math.Pow(speed/vessel.DesignSpeed, 3) * days
+}
+
+// Emissions of a route, unit tonnes
+type Emissions struct {
+	Speed float64        `json:"speed"` // speed outside of speed limits, unit knots
+	Fuel  float64        `json:"fuel"`
+	CO2   float64        `json:"co2"`
+	Legs  []LegEmissions `json:"legs"` // emissions of each leg of the route
+}
+
+// Emissions of a leg, unit tonnes
+type LegEmissions struct {
+	Fuel float64 `json:"fuel"`
+	CO2  float64 `json:"co2"`
+}
+
+// HasConsumption reports whether the consumption of the vessel can be estimated
+func (v VesselProfile) HasConsumption() bool {
+	return v.DesignSpeed > 0 && v.DesignFuel > 0
+}
+
+// Tonnes of CO2 per tonne of fuel of the vessel
+func (v VesselProfile) co2Factor() float64 {
+	if v.CO2Factor > 0 {
+		return v.CO2Factor
+	}
+	return DefaultCO2Factor
+}
+
+// Profile of the requested vessel and its speed, which is the requested speed or the design speed of the vessel.
+// ok is false iff no vessel has been requested or its consumption is unknown.
+func (sr ShipRouter1[N, E]) consumingVessel(req RouteRequest) (vessel VesselProfile, speed float64, ok bool) {
+	if req.Vessel == "" || sr.Vessels == nil {
+		return VesselProfile{}, 0, false
+	}
+	vessel, ok = sr.Vessels.Profile(req.Vessel)
+	if !ok || !vessel.HasConsumption() {
+		return VesselProfile{}, 0, false
+	}
+	speed = req.Speed
+	if speed == 0 {
+		speed = vessel.DesignSpeed
+	}
+	return vessel, speed, true
+}
+
+// Consumption model of the ship router
+func (sr ShipRouter1[N, E]) consumption() ConsumptionModel {
+	if sr.Consumption == nil {
+		return CubicLaw{}
+	}
+	return sr.Consumption
+}
+
+// Estimate the emissions of each leg, which is given by its waypoints, at the speed (unit knots).
+// Each segment of a leg is travelled at the lowest speed limit of the applied zones containing its midpoint, unless the speed is lower.
+func (sr ShipRouter1[N, E]) emissions(legs [][]Point, vessel VesselProfile, speed float64, sets []string) *Emissions {
+	model := sr.consumption()
+	var limits []float64
+	if sr.Zones != nil {
+		limits = sr.Zones.speedLimits(sets)
+	}
+	emissions := &Emissions{Speed: speed, Legs: make([]LegEmissions, 0, len(legs))}
+	for _, waypoints := range legs {
+		fuel := 0.0
+		for i := 1; i < len(waypoints); i++ {
+			segmentSpeed := speed
+			if limits != nil {
+				segmentSpeed = sr.Zones.segmentSpeed(waypoints[i-1], waypoints[i], speed, limits)
+			}
+			fuel += model.Fuel(vessel, float64(distance(waypoints[i-1], waypoints[i])), segmentSpeed)
+		}
+		leg := LegEmissions{Fuel: fuel, CO2: fuel * vessel.co2Factor()}
+		emissions.Legs = append(emissions.Legs, leg)
+		emissions.Fuel += leg.Fuel
+		emissions.CO2 += leg.CO2
+	}
+	return emissions
+}
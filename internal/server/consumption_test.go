@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+// Tanker consuming 60 tonnes of fuel per day at 15 knots
+var testTanker = VesselProfile{Name: "tanker", Draft: 10, DesignSpeed: 15, DesignFuel: 60}
+
+func TestCubicLaw(t *testing.T) {
+	day := 15 * 24.0 * metersPerNauticalMile // distance travelled in one day at the design speed
+	tests := []struct {
+		distance, speed, want float64
+	}{
+		{day, 15, 60},
+		{day, 7.5, 15}, // twice the time at an eighth of the daily consumption
+		{day / 2, 15, 30},
+		{day, 0, 0},
+	}
+	for _, test := range tests {
+		if got := (CubicLaw{}).Fuel(testTanker, test.distance, test.speed); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("Fuel(%v, %v) = %v, expected %v", test.distance, test.speed, got, test.want)
+		}
+	}
+	if got := (CubicLaw{}).Fuel(VesselProfile{}, day, 15); got != 0 {
+		t.Errorf("Consumption of a vessel without coefficients should be zero, got %v", got)
+	}
+}
+
+func newConsumptionTestRouter(t *testing.T, graph *testGraph) ShipRouter1[g.GeoPoint, g.WeightedHalfEdge[int]] {
+	sr := newTestShipRouter(graph)
+	vessels, err := NewVesselIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph, append([]VesselProfile{testTanker}, testVessels...), Restrictions{})
+	if err != nil {
+		t.Fatalf("NewVesselIndex failed: %v", err)
+	}
+	sr.Vessels = vessels
+	return sr
+}
+
+func TestProcessRequestEmissions(t *testing.T) {
+	sr := newConsumptionTestRouter(t, gridGraph(5, 5))
+	req := RouteRequest{Origin: Point{Lat: 0, Lon: 0}, Via: []Point{{Lat: 2, Lon: 2}}, Destination: Point{Lat: 4, Lon: 0}, Vessel: "feeder"}
+	if res := mustRoute(t, sr, req); res.Emissions != nil {
+		t.Errorf("Emissions of a vessel without consumption coefficients should be omitted")
+	}
+
+	req.Vessel = testTanker.Name
+	res := mustRoute(t, sr, req)
+	if res.Emissions == nil || len(res.Emissions.Legs) != 2 || res.Emissions.Speed != testTanker.DesignSpeed {
+		t.Fatalf("Expected emissions of two legs at the design speed, got %+v", res.Emissions)
+	}
+	if want := (CubicLaw{}).Fuel(testTanker, float64(res.Path.Length), testTanker.DesignSpeed); math.Abs(res.Emissions.Fuel-want) > 1e-6 {
+		t.Errorf("Fuel is %v t, expected %v t", res.Emissions.Fuel, want)
+	}
+	legs := res.Emissions.Legs
+	if math.Abs(legs[0].Fuel+legs[1].Fuel-res.Emissions.Fuel) > 1e-9 || math.Abs(res.Emissions.CO2-DefaultCO2Factor*res.Emissions.Fuel) > 1e-9 {
+		t.Errorf("Unexpected emissions %+v", res.Emissions)
+	}
+
+	// at half the speed, the consumption per distance is a quarter
+	req.Speed = testTanker.DesignSpeed / 2
+	if slow := mustRoute(t, sr, req); math.Abs(slow.Emissions.Fuel-res.Emissions.Fuel/4) > 1e-6 {
+		t.Errorf("Fuel at half the speed is %v t, expected %v t", slow.Emissions.Fuel, res.Emissions.Fuel/4)
+	}
+}
+
+func TestProcessRequestMinimizeFuel(t *testing.T) {
+	graph := gridGraph(5, 5)
+	sr := newConsumptionTestRouter(t, graph)
+	// the high multiplier of the zone causes a detour, but the speed limit inside the zone saves fuel
+	zone := stripZone(t, 3)
+	zone.MaxSpeed = 5
+	sr.Zones = NewZoneIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph, []Zone{zone})
+	req := RouteRequest{Origin: Point{Lat: 2, Lon: 0}, Destination: Point{Lat: 2, Lon: 4}, Vessel: testTanker.Name, Zones: []string{"eca"}}
+
+	detour := mustRoute(t, sr, req)
+	req.Optimize = OptimizeFuel
+	direct := mustRoute(t, sr, req)
+	if direct.Path.Length >= detour.Path.Length || len(direct.Zones) != 1 || direct.Zones[0].MaxSpeed != 5 {
+		t.Errorf("Expected the direct route through the zone, got length %d and zones %+v", direct.Path.Length, direct.Zones)
+	}
+	if direct.Emissions.Fuel >= detour.Emissions.Fuel {
+		t.Errorf("Minimizing fuel consumes %v t, but the detour only %v t", direct.Emissions.Fuel, detour.Emissions.Fuel)
+	}
+
+	var reqErr *RequestError
+	req.Vessel = "feeder"
+	if _, err := sr.ProcessRequest(context.Background(), req, false); !errors.As(err, &reqErr) || reqErr.Field != "optimize" {
+		t.Errorf("Expected invalid value error for field optimize, got %v", err)
+	}
+	req.Optimize = "time"
+	if _, err := sr.ProcessRequest(context.Background(), req, false); !errors.As(err, &reqErr) || reqErr.Field != "optimize" {
+		t.Errorf("Expected invalid value error for field optimize, got %v", err)
+	}
+}
@@ -1,23 +1,155 @@
 package server
 
+import (
+	"time"
+
+	"github.com/paulmach/orb/geojson"
+)
+
 type Point struct {
 	Lat float64 `json:"lat"`
 	Lon float64 `json:"lon"`
 }
 
 type RouteRequest struct {
-	Origin      Point `json:"origin"`
-	Destination Point `json:"destination"`
+	Origin      Point   `json:"origin"`
+	Destination Point   `json:"destination"`
+	Via         []Point `json:"via,omitempty"`   // ordered list of intermediate points
+	Speed       float64 `json:"speed,omitempty"` // planned speed, unit knots
+	// prepend the requested origin and append the requested destination to the waypoints of the path
+	IncludeRequestedPoints bool `json:"include_requested_points,omitempty"`
+	// replace the staircase of the grid graph by direct great circle arcs, which do not cross any coastline
+	Smooth bool `json:"smooth,omitempty"`
+	// GeoJSON polygons or multipolygons, whose nodes are excluded from the search for this request
+	AvoidAreas []geojson.Geometry `json:"avoid_areas,omitempty"`
+	// sets of penalty zones, whose multipliers are applied to the cost of the edges inside the zones
+	Zones []string `json:"zones,omitempty"`
+	// name of a vessel profile, whose draft, air draft and beam restrict the usable nodes and edges
+	Vessel string `json:"vessel,omitempty"`
+	// ids of chokepoints such as suez or panama, whose nodes are excluded from the search
+	Avoid []string `json:"avoid,omitempty"`
+	// departure time in RFC 3339 format, which requires a speed and adds passage times and the ETA to the voyage
+	Departure *time.Time `json:"departure,omitempty"`
+	// objective of the search, either distance (default) or fuel, which requires a vessel with known consumption
+	Optimize string `json:"optimize,omitempty"`
+	// report the distance of each waypoint to the coast and the legs closer to the coast than the safety margin
+	CoastDistance bool `json:"coast_distance,omitempty"`
+	// minimum distance of each leg to the coast in meters, defaults to the safety margin of the server
+	SafetyMargin int `json:"safety_margin,omitempty"`
 }
 
 type RouteResponse struct {
-	Exists      bool    `json:"exists"`
-	Path        Path    `json:"path,omitempty"`
-	Time        int64   `json:"time"`
-	SearchSpace []Point `json:"search_space,omitempty"`
+	// always true, since routes that do not exist are reported as RequestError with code unreachable. Kept for compatibility.
+	Exists      bool           `json:"exists"`
+	Path        Path           `json:"path,omitempty"`
+	Legs        []Leg          `json:"legs,omitempty"`
+	Time        int64          `json:"time"`
+	SearchSpace []Point        `json:"search_space,omitempty"`
+	Speed       float64        `json:"speed,omitempty"` // planned speed of the request, unit knots
+	CacheHit    bool           `json:"cache_hit"`       // true iff the paths of all legs have been taken from the cache
+	Origin      SnappedPoint   `json:"origin"`
+	Destination SnappedPoint   `json:"destination"`
+	Via         []SnappedPoint `json:"via,omitempty"`
+	Smoothing   *Smoothing     `json:"smoothing,omitempty"` // only present iff smoothing has been requested
+	Cost        int            `json:"cost,omitempty"`      // cost of the path including penalties, only present iff zones have been applied
+	Zones       []ZoneDistance `json:"zones,omitempty"`     // distances travelled inside zones along the edges of the graph
+	Voyage      *Voyage        `json:"voyage,omitempty"`    // only present iff a speed has been requested
+	Emissions   *Emissions     `json:"emissions,omitempty"` // only present iff the consumption of the requested vessel is known
+	Clearance   *Clearance     `json:"clearance,omitempty"` // only present iff the distance to the coast has been requested
+}
+
+// A requested point and the node it has been snapped to
+type SnappedPoint struct {
+	Requested Point `json:"requested"`
+	Snapped   Point `json:"snapped"`
+	Distance  int   `json:"distance"` // great circle distance between the requested and the snapped point, unit meters
+}
+
+// A leg is the part of a route between two consecutive points of the request
+type Leg struct {
+	Exists   bool  `json:"exists"` // always true, kept for compatibility like RouteResponse.Exists
+	Length   int   `json:"length"`
+	Time     int64 `json:"time"`
+	CacheHit bool  `json:"cache_hit"` // true iff the path of the leg has been taken from the cache
 }
 
 type Path struct {
 	Waypoints []Point `json:"waypoints"`
 	Length    int     `json:"length"`
 }
+
+// Request the lengths of the shortest paths between all pairs of sources and targets
+type MatrixRequest struct {
+	Sources []Point `json:"sources"`
+	Targets []Point `json:"targets"`
+}
+
+type MatrixResponse struct {
+	// Lengths[i][j] is the length of the shortest path from the i-th source to the j-th target and -1 iff such a path does not exist
+	Lengths [][]int `json:"lengths"`
+	// Exists[i][j] states whether a path from the i-th source to the j-th target exists
+	Exists [][]bool `json:"exists"`
+	Time   int64    `json:"time"`
+}
+
+// Request up to k routes between origin and destination, which are sufficiently different from each other
+type AlternativesRequest struct {
+	Origin      Point `json:"origin"`
+	Destination Point `json:"destination"`
+	// maximum number of routes including the best route, defaults to DefaultAlternatives
+	Alternatives int `json:"alternatives,omitempty"`
+	// maximum ratio of the length of an alternative route to the length of the best route, defaults to DefaultMaxStretch
+	MaxStretch float64 `json:"max_stretch,omitempty"`
+	// maximum fraction of the length of a route, which it may share with any other route, defaults to DefaultMaxOverlap
+	MaxOverlap float64 `json:"max_overlap,omitempty"`
+}
+
+type AlternativesResponse struct {
+	Routes []AlternativeRoute `json:"routes"` // the best route followed by the alternative routes in ascending order of their length
+	Time   int64              `json:"time"`
+}
+
+type AlternativeRoute struct {
+	Path    Path    `json:"path"`
+	Stretch float64 `json:"stretch"` // ratio of the length of the route to the length of the best route
+	Overlap float64 `json:"overlap"` // fraction of the length of the route, which it shares with the best route
+}
+
+// Request to check whether the great circle arcs between consecutive waypoints of a track cross land
+type ValidationRequest struct {
+	Waypoints []Point `json:"waypoints"`
+}
+
+type ValidationResponse struct {
+	Valid      bool        `json:"valid"`      // true iff no leg of the track touches land
+	Length     int         `json:"length"`     // total length of the track, unit meters
+	Violations []Violation `json:"violations"` // legs touching land in the order of the track
+	Polygons   []int       `json:"polygons"`   // ascending ids of all coastline polygons touched by the track
+	Time       int64       `json:"time"`
+}
+
+// Violation of a leg of a track, which touches land
+type Violation struct {
+	Leg      int   `json:"leg"`      // the leg from waypoint Leg to waypoint Leg+1
+	Point    Point `json:"point"`    // first point of the leg on land, i.e. its start if that is on land or its first crossing of a coastline
+	Polygons []int `json:"polygons"` // ids of the coastline polygons touched by the leg
+}
+
+// Request the nodes being reachable from the origin within the given distances
+type IsochroneRequest struct {
+	Origin Point `json:"origin"`
+	// Upper bounds of the distance bands in ascending order, unit meters
+	Bands []int `json:"bands"`
+}
+
+type IsochroneResponse struct {
+	Bands []IsochroneBand
+	Time  int64
+}
+
+// Points whose distance to the origin is in [MinDistance, MaxDistance)
+type IsochroneBand struct {
+	MinDistance int
+	MaxDistance int
+	Points      []Point
+}
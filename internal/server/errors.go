@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"math"
+)
+
+// Error codes of structured error responses
+const (
+	ErrorCodeInvalidRequest     = "invalid_request"     // the request body cannot be decoded
+	ErrorCodeInvalidCoordinates = "invalid_coordinates" // latitude or longitude out of range
+	ErrorCodeInvalidValue       = "invalid_value"       // any other value of the request is out of range
+	ErrorCodePointOnLand        = "point_on_land"       // the point is farther away from the graph than the maximum snapping distance
+	ErrorCodeUnreachable        = "unreachable"         // no route to the point exists
+	ErrorCodePointInAvoidArea   = "point_in_avoid_area" // the point is snapped to a node inside an avoid area or an avoided chokepoint of the request
+	ErrorCodeRestrictedPoint    = "restricted_point"    // the point is snapped to a node, which must not be used by the vessel of the request
+	ErrorCodeUnknownRouter      = "unknown_router"
+	ErrorCodeUnsupportedFormat  = "unsupported_format"
+	ErrorCodeNotReady           = "not_ready"
+	ErrorCodeNotAvailable       = "not_available" // the feature is not enabled by the configuration of the server
+	ErrorCodeTimeout            = "timeout"
+	ErrorCodeCancelled          = "cancelled"
+	ErrorCodeInternal           = "internal_error"
+)
+
+// RequestError is the structured error body of a request that cannot be answered
+type RequestError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"` // offending field of the request, e.g. origin or via[1]
+}
+
+func (e *RequestError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: %s (field %s)", e.Code, e.Message, e.Field)
+}
+
+// Create a RequestError with a formatted message
+func NewRequestError(code string, field string, format string, a ...any) *RequestError {
+	return &RequestError{Code: code, Message: fmt.Sprintf(format, a...), Field: field}
+}
+
+// Validate checks that the point is within the ranges of latitude [-90, 90] and longitude [-180, 180].
+// The field names the point in the error.
+func (p Point) Validate(field string) error {
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return NewRequestError(ErrorCodeInvalidCoordinates, field, "latitude %v is not in [-90, 90]", p.Lat)
+	}
+	if math.IsNaN(p.Lon) || p.Lon < -180 || p.Lon > 180 {
+		return NewRequestError(ErrorCodeInvalidCoordinates, field, "longitude %v is not in [-180, 180]", p.Lon)
+	}
+	return nil
+}
+
+// Range of the planned speed of route requests, unit knots.
+// Slower or faster speeds would overflow the passage times of the voyage.
+const (
+	MinSpeed = 0.1
+	MaxSpeed = 50
+)
+
+// Validate checks the coordinates of all points, the speed, the departure and the objective of the request
+func (req RouteRequest) Validate() error {
+	if err := req.Origin.Validate("origin"); err != nil {
+		return err
+	}
+	for i, via := range req.Via {
+		if err := via.Validate(viaField(i)); err != nil {
+			return err
+		}
+	}
+	if err := req.Destination.Validate("destination"); err != nil {
+		return err
+	}
+	// zero speed means that no voyage is planned; NaN and infinities fail the range check
+	if req.Speed != 0 && !(req.Speed >= MinSpeed && req.Speed <= MaxSpeed) {
+		return NewRequestError(ErrorCodeInvalidValue, "speed", "speed %v is not in [%v, %v]", req.Speed, MinSpeed, MaxSpeed)
+	}
+	if req.Departure != nil && req.Speed == 0 {
+		return NewRequestError(ErrorCodeInvalidValue, "speed", "a departure requires a positive speed")
+	}
+	if req.Optimize != "" && req.Optimize != OptimizeDistance && req.Optimize != OptimizeFuel {
+		return NewRequestError(ErrorCodeInvalidValue, "optimize", "unknown objective %s", req.Optimize)
+	}
+	if req.SafetyMargin < 0 {
+		return NewRequestError(ErrorCodeInvalidValue, "safety_margin", "safety margin %d must not be negative", req.SafetyMargin)
+	}
+	return nil
+}
+
+// Name of the field of the i-th stop of a route request, i.e. origin, via[0], ..., via[n-1], destination
+func stopField(i int, stopCount int) string {
+	switch i {
+	case 0:
+		return "origin"
+	case stopCount - 1:
+		return "destination"
+	default:
+		return viaField(i - 1)
+	}
+}
+
+func viaField(i int) string {
+	return fmt.Sprintf("via[%d]", i)
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+// Assert that err is a *RequestError with the given code and field
+func assertRequestError(t *testing.T, err error, code, field string) {
+	t.Helper()
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) {
+		t.Fatalf("Expected a RequestError with code %s, got %v", code, err)
+	}
+	if reqErr.Code != code || reqErr.Field != field {
+		t.Errorf("Expected code %s and field %s, got %+v", code, field, reqErr)
+	}
+}
+
+func TestRouteRequestValidate(t *testing.T) {
+	valid := RouteRequest{Origin: Point{Lat: 90, Lon: -180}, Destination: Point{Lat: -90, Lon: 180}, Via: []Point{{Lat: 0, Lon: 0}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Request should be valid: %v", err)
+	}
+
+	req := valid
+	req.Origin.Lat = 90.5
+	assertRequestError(t, req.Validate(), ErrorCodeInvalidCoordinates, "origin")
+
+	req = valid
+	req.Destination.Lon = math.NaN()
+	assertRequestError(t, req.Validate(), ErrorCodeInvalidCoordinates, "destination")
+
+	req = valid
+	req.Via = []Point{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 181}}
+	assertRequestError(t, req.Validate(), ErrorCodeInvalidCoordinates, "via[1]")
+
+	for _, speed := range []float64{-1, math.NaN(), math.Inf(1), 1e-300, MaxSpeed + 1} {
+		req = valid
+		req.Speed = speed
+		assertRequestError(t, req.Validate(), ErrorCodeInvalidValue, "speed")
+	}
+
+	for _, speed := range []float64{0, MinSpeed, MaxSpeed} {
+		req = valid
+		req.Speed = speed
+		if err := req.Validate(); err != nil {
+			t.Errorf("Speed %v should be valid: %s", speed, err)
+		}
+	}
+}
+
+func TestStopField(t *testing.T) {
+	for i, want := range []string{"origin", "via[0]", "via[1]", "destination"} {
+		if got := stopField(i, 4); got != want {
+			t.Errorf("stopField(%d, 4) = %s, expected %s", i, got, want)
+		}
+	}
+}
+
+func TestProcessRequestPointOnLand(t *testing.T) {
+	sr := newTestShipRouter(gridGraph(3, 3))
+	sr.MaxSnapDistance = 50000
+
+	// about 111 km away from the closest node
+	req := RouteRequest{Origin: Point{Lat: 0, Lon: 0}, Via: []Point{{Lat: 1, Lon: 3}}, Destination: Point{Lat: 2, Lon: 2}}
+	_, err := sr.ProcessRequest(context.Background(), req, false)
+	assertRequestError(t, err, ErrorCodePointOnLand, "via[0]")
+
+	sr.MaxSnapDistance = 0
+	if _, err := sr.ProcessRequest(context.Background(), req, false); err != nil {
+		t.Errorf("Snapping distance should not be limited: %v", err)
+	}
+}
+
+func TestProcessRequestUnreachable(t *testing.T) {
+	// two islands without any connection
+	alg := &g.AdjacencyListGraph[g.GeoPoint, g.WeightedHalfEdge[int]]{}
+	alg.AppendNode(g.GeoPoint{Lat: 0, Lon: 0})
+	alg.AppendNode(g.GeoPoint{Lat: 0, Lon: 1})
+	alg.AppendNode(g.GeoPoint{Lat: 10, Lon: 10})
+	alg.InsertHalfEdge(0, g.WeightedHalfEdge[int]{To_: 1, Weight_: 111000})
+	alg.InsertHalfEdge(1, g.WeightedHalfEdge[int]{To_: 0, Weight_: 111000})
+	sr := newTestShipRouter(g.NewAdjacencyArrayFromGraph[g.GeoPoint, g.WeightedHalfEdge[int]](alg))
+
+	req := RouteRequest{Origin: Point{Lat: 0, Lon: 0}, Destination: Point{Lat: 10, Lon: 10}}
+	_, err := sr.ProcessRequest(context.Background(), req, false)
+	assertRequestError(t, err, ErrorCodeUnreachable, "destination")
+}
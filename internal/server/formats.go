@@ -0,0 +1,179 @@
+package server
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// Output formats of a RouteResponse
+const (
+	FormatJSON    = "json"
+	FormatGeoJSON = "geojson"
+	FormatGPX     = "gpx"
+	FormatKML     = "kml"
+	FormatRTZ     = "rtz"
+)
+
+// RouteEncoder writes a RouteResponse in a specific output format
+type RouteEncoder struct {
+	ContentType string
+	Encode      func(w io.Writer, res RouteResponse) error
+}
+
+// Registry of the supported output formats
+var routeEncoders = map[string]RouteEncoder{
+	FormatJSON:    {ContentType: "application/json", Encode: EncodeJSON},
+	FormatGeoJSON: {ContentType: "application/geo+json", Encode: EncodeGeoJSON},
+	FormatGPX:     {ContentType: "application/gpx+xml", Encode: EncodeGPX},
+	FormatKML:     {ContentType: "application/vnd.google-earth.kml+xml", Encode: EncodeKML},
+	FormatRTZ:     {ContentType: "application/rtz+xml", Encode: EncodeRTZ},
+}
+
+// GetRouteEncoder returns the encoder of the format and false iff the format is not supported
+func GetRouteEncoder(format string) (RouteEncoder, bool) {
+	encoder, ok := routeEncoders[strings.ToLower(format)]
+	return encoder, ok
+}
+
+// NegotiateFormat selects the output format of a route.
+// An explicitly requested format (e.g. the query parameter 'format') takes precedence over the media ranges of the Accept header.
+// The default format is FormatJSON. The second return value is false iff none of the requested formats is supported.
+func NegotiateFormat(format string, accept string) (string, bool) {
+	if format != "" {
+		format = strings.ToLower(format)
+		_, ok := routeEncoders[format]
+		return format, ok
+	}
+	if strings.TrimSpace(accept) == "" {
+		return FormatJSON, true
+	}
+
+	type mediaRange struct {
+		mediaType string
+		quality   float64
+	}
+	mediaRanges := make([]mediaRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mr := mediaRange{mediaType: strings.ToLower(strings.TrimSpace(params[0])), quality: 1}
+		for _, param := range params[1:] {
+			if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.TrimSpace(key) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					mr.quality = q
+				}
+			}
+		}
+		if mr.quality > 0 {
+			mediaRanges = append(mediaRanges, mr)
+		}
+	}
+	sort.SliceStable(mediaRanges, func(i, j int) bool { return mediaRanges[i].quality > mediaRanges[j].quality })
+
+	for _, mr := range mediaRanges {
+		switch mr.mediaType {
+		case "*/*", "application/*":
+			return FormatJSON, true
+		}
+		for format, encoder := range routeEncoders {
+			if encoder.ContentType == mr.mediaType {
+				return format, true
+			}
+		}
+	}
+	return "", false
+}
+
+// EncodeJSON writes the RouteResponse as plain JSON
+func EncodeJSON(w io.Writer, res RouteResponse) error {
+	return json.NewEncoder(w).Encode(res)
+}
+
+// EncodeGeoJSON writes the path as a GeoJSON LineString feature
+func EncodeGeoJSON(w io.Writer, res RouteResponse) error {
+	lineString := make(orb.LineString, 0, len(res.Path.Waypoints))
+	for _, wp := range res.Path.Waypoints {
+		lineString = append(lineString, orb.Point{wp.Lon, wp.Lat})
+	}
+	feature := geojson.NewFeature(lineString)
+	feature.Properties["exists"] = res.Exists
+	feature.Properties["length"] = res.Path.Length
+	feature.Properties["time"] = res.Time
+	return json.NewEncoder(w).Encode(feature)
+}
+
+type gpxDocument struct {
+	XMLName xml.Name   `xml:"http://www.topografix.com/GPX/1/1 gpx"`
+	Version string     `xml:"version,attr"`
+	Creator string     `xml:"creator,attr"`
+	Routes  []gpxRoute `xml:"rte"`
+}
+
+type gpxRoute struct {
+	Name   string     `xml:"name"`
+	Desc   string     `xml:"desc,omitempty"`
+	Points []gpxPoint `xml:"rtept"`
+}
+
+type gpxPoint struct {
+	Lat float64 `xml:"lat,attr"`
+	Lon float64 `xml:"lon,attr"`
+}
+
+// EncodeGPX writes the path as a GPX 1.1 route (<rte>)
+func EncodeGPX(w io.Writer, res RouteResponse) error {
+	route := gpxRoute{Name: "Route", Desc: fmt.Sprintf("length: %d m", res.Path.Length), Points: make([]gpxPoint, 0, len(res.Path.Waypoints))}
+	for _, wp := range res.Path.Waypoints {
+		route.Points = append(route.Points, gpxPoint{Lat: wp.Lat, Lon: wp.Lon})
+	}
+	doc := gpxDocument{Version: "1.1", Creator: "osm-ship-routing", Routes: []gpxRoute{route}}
+	return encodeXML(w, doc)
+}
+
+type kmlDocument struct {
+	XMLName    xml.Name       `xml:"http://www.opengis.net/kml/2.2 kml"`
+	Placemarks []kmlPlacemark `xml:"Document>Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name        string        `xml:"name"`
+	Description string        `xml:"description,omitempty"`
+	LineString  kmlLineString `xml:"LineString"`
+}
+
+type kmlLineString struct {
+	Tessellate  int    `xml:"tessellate"`
+	Coordinates string `xml:"coordinates"`
+}
+
+// EncodeKML writes the path as a KML LineString placemark
+func EncodeKML(w io.Writer, res RouteResponse) error {
+	// KML expects tuples of lon,lat[,alt] separated by whitespace
+	coordinates := make([]string, 0, len(res.Path.Waypoints))
+	for _, wp := range res.Path.Waypoints {
+		coordinates = append(coordinates, strconv.FormatFloat(wp.Lon, 'f', -1, 64)+","+strconv.FormatFloat(wp.Lat, 'f', -1, 64))
+	}
+	placemark := kmlPlacemark{Name: "Route", Description: fmt.Sprintf("length: %d m", res.Path.Length), LineString: kmlLineString{Tessellate: 1, Coordinates: strings.Join(coordinates, " ")}}
+	doc := kmlDocument{Placemarks: []kmlPlacemark{placemark}}
+	return encodeXML(w, doc)
+}
+
+func encodeXML(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
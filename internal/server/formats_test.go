@@ -0,0 +1,82 @@
+package server
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+var exampleResponse = RouteResponse{Exists: true, Path: Path{Waypoints: []Point{{Lat: 51.9, Lon: 4.1}, {Lat: 36.1, Lon: -5.4}}, Length: 2400000}, Time: 12}
+
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		format, accept string
+		want           string
+		ok             bool
+	}{
+		{"", "", FormatJSON, true},
+		{"GPX", "application/json", FormatGPX, true},
+		{"shapefile", "", "shapefile", false},
+		{"", "application/geo+json", FormatGeoJSON, true},
+		{"", "text/html, application/vnd.google-earth.kml+xml;q=0.9, */*;q=0.1", FormatKML, true},
+		{"", "application/json;q=0.5, application/gpx+xml", FormatGPX, true},
+		{"", "*/*", FormatJSON, true},
+		{"", "text/html, application/gpx+xml;q=0", "", false},
+	}
+	for _, c := range cases {
+		got, ok := NegotiateFormat(c.format, c.accept)
+		if got != c.want || ok != c.ok {
+			t.Errorf("NegotiateFormat(%q, %q) = (%q, %t), expected (%q, %t)", c.format, c.accept, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestEncodeGeoJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeGeoJSON(&buf, exampleResponse); err != nil {
+		t.Fatal(err)
+	}
+	feature, err := geojson.UnmarshalFeature(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lineString, ok := feature.Geometry.(orb.LineString)
+	if !ok || len(lineString) != 2 {
+		t.Fatalf("Expected LineString with two points, got %v", feature.Geometry)
+	}
+	// GeoJSON positions are ordered lon, lat
+	if lineString[0] != (orb.Point{4.1, 51.9}) {
+		t.Errorf("Unexpected first position %v", lineString[0])
+	}
+	if feature.Properties.MustInt("length") != exampleResponse.Path.Length {
+		t.Errorf("Unexpected length property %v", feature.Properties["length"])
+	}
+}
+
+func TestEncodeGPX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeGPX(&buf, exampleResponse); err != nil {
+		t.Fatal(err)
+	}
+	var doc gpxDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Routes) != 1 || len(doc.Routes[0].Points) != 2 || doc.Routes[0].Points[1] != (gpxPoint{Lat: 36.1, Lon: -5.4}) {
+		t.Errorf("Unexpected GPX document %+v", doc)
+	}
+}
+
+func TestEncodeKML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeKML(&buf, exampleResponse); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<coordinates>4.1,51.9 -5.4,36.1</coordinates>") {
+		t.Errorf("Unexpected KML document %s", buf.String())
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	g "github.com/dmholtz/graffiti/graph"
+
+	gr "github.com/dmholtz/osm-ship-routing/pkg/graph"
+)
+
+// geoGraph presents a graffiti graph as a graph of the pkg/graph package.
+// Edges are converted whenever they are requested, which is slower than accessing the graffiti graph directly.
+type geoGraph[N IGeoPoint, E g.IWeightedHalfEdge[int]] struct {
+	graph g.Graph[N, E]
+}
+
+// AsGraph returns a view of the graffiti graph, which implements the Graph interface of the pkg/graph package.
+// Node and edge ids of the view coincide with those of the graffiti graph.
+func AsGraph[N IGeoPoint, E g.IWeightedHalfEdge[int]](graph g.Graph[N, E]) gr.Graph {
+	return geoGraph[N, E]{graph: graph}
+}
+
+func (gg geoGraph[N, E]) GetNode(id gr.NodeId) gr.Node {
+	p := getPoint(gg.graph.GetNode(id))
+	return gr.Node{Lat: p.Lat, Lon: p.Lon}
+}
+
+func (gg geoGraph[N, E]) GetHalfEdgesFrom(id gr.NodeId) []gr.HalfEdge {
+	edges := gg.graph.GetHalfEdgesFrom(id)
+	halfEdges := make([]gr.HalfEdge, len(edges))
+	for i, edge := range edges {
+		halfEdges[i] = gr.HalfEdge{To: edge.To(), Distance: edge.Weight()}
+	}
+	return halfEdges
+}
+
+func (gg geoGraph[N, E]) NodeCount() int {
+	return gg.graph.NodeCount()
+}
+
+func (gg geoGraph[N, E]) EdgeCount() int {
+	return gg.graph.EdgeCount()
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"math"
+	"time"
+
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+// Describes a graph loaded by the server
+type GraphInfo struct {
+	File        string      `json:"file"`
+	NodeCount   int         `json:"node_count"`
+	EdgeCount   int         `json:"edge_count"`
+	BoundingBox BoundingBox `json:"bounding_box"`
+	Routers     []string    `json:"routers"`   // ids of the routers operating on the graph
+	LoadedAt    time.Time   `json:"loaded_at"` // time when loading the graph has been completed
+	LoadTime    int64       `json:"load_time"` // time required to load the graph and build its routers, unit milliseconds
+}
+
+type BoundingBox struct {
+	LatMin float64 `json:"lat_min"`
+	LatMax float64 `json:"lat_max"`
+	LonMin float64 `json:"lon_min"`
+	LonMax float64 `json:"lon_max"`
+}
+
+// Create a GraphInfo and compute the bounding box of the graph's nodes
+func NewGraphInfo[N IGeoPoint, E g.IHalfEdge](file string, graph g.Graph[N, E], routers []string, loadTime time.Duration) GraphInfo {
+	bbox := BoundingBox{LatMin: math.Inf(1), LatMax: math.Inf(-1), LonMin: math.Inf(1), LonMax: math.Inf(-1)}
+	for nodeId := 0; nodeId < graph.NodeCount(); nodeId++ {
+		p := getPoint(graph.GetNode(nodeId))
+		bbox.LatMin = math.Min(bbox.LatMin, p.Lat)
+		bbox.LatMax = math.Max(bbox.LatMax, p.Lat)
+		bbox.LonMin = math.Min(bbox.LonMin, p.Lon)
+		bbox.LonMax = math.Max(bbox.LonMax, p.Lon)
+	}
+	if graph.NodeCount() == 0 {
+		// infinite values cannot be encoded as JSON
+		bbox = BoundingBox{}
+	}
+	return GraphInfo{File: file, NodeCount: graph.NodeCount(), EdgeCount: graph.EdgeCount(), BoundingBox: bbox, Routers: routers, LoadedAt: time.Now(), LoadTime: loadTime.Milliseconds()}
+}
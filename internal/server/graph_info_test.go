@@ -0,0 +1,32 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+func TestNewGraphInfo(t *testing.T) {
+	graph := gridGraph(3, 4)
+	info := NewGraphInfo[g.GeoPoint, g.WeightedHalfEdge[int]]("grid.fmi", graph, []string{"dijkstra"}, 1500*time.Millisecond)
+
+	if info.NodeCount != 12 || info.EdgeCount != graph.EdgeCount() {
+		t.Errorf("Unexpected node count %d or edge count %d", info.NodeCount, info.EdgeCount)
+	}
+	want := BoundingBox{LatMin: 0, LatMax: 2, LonMin: 0, LonMax: 3}
+	if info.BoundingBox != want {
+		t.Errorf("Bounding box is %v, expected %v", info.BoundingBox, want)
+	}
+	if info.LoadTime != 1500 {
+		t.Errorf("Load time is %d ms, expected 1500 ms", info.LoadTime)
+	}
+}
+
+func TestNewGraphInfoEmpty(t *testing.T) {
+	graph := &g.AdjacencyListGraph[g.GeoPoint, g.WeightedHalfEdge[int]]{}
+	info := NewGraphInfo[g.GeoPoint, g.WeightedHalfEdge[int]]("empty.fmi", graph, nil, 0)
+	if info.BoundingBox != (BoundingBox{}) {
+		t.Errorf("Bounding box of an empty graph is %v", info.BoundingBox)
+	}
+}
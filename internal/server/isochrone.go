@@ -0,0 +1,132 @@
+package server
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	g "github.com/dmholtz/graffiti/graph"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// Validate checks the coordinates of the origin and that the distance bands are positive and strictly ascending
+func (req IsochroneRequest) Validate() error {
+	if err := req.Origin.Validate("origin"); err != nil {
+		return err
+	}
+	if len(req.Bands) == 0 {
+		return NewRequestError(ErrorCodeInvalidValue, "bands", "at least one distance band is required")
+	}
+	for i, band := range req.Bands {
+		if band <= 0 {
+			return NewRequestError(ErrorCodeInvalidValue, "bands", "distance bands must be positive")
+		}
+		if i > 0 && band <= req.Bands[i-1] {
+			return NewRequestError(ErrorCodeInvalidValue, "bands", "distance bands must be strictly ascending")
+		}
+	}
+	return nil
+}
+
+// ProcessIsochroneRequest reports the nodes being reachable from the snapped origin, grouped by distance bands.
+// A bounded Dijkstra search settles all nodes up to the largest distance band.
+// A *RequestError is returned if the request is invalid or the origin is farther away from the graph than the maximum snapping distance.
+//
+// Note that the search is conducted by Dijkstra's algorithm on the graph of the ShipRouter, regardless of its router type.
+func (sr ShipRouter1[N, E]) ProcessIsochroneRequest(ctx context.Context, req IsochroneRequest) (IsochroneResponse, error) {
+	startTime := time.Now()
+
+	if err := req.Validate(); err != nil {
+		return IsochroneResponse{}, err
+	}
+
+	bands := make([]IsochroneBand, len(req.Bands))
+	for i, maxDistance := range req.Bands {
+		bands[i] = IsochroneBand{MaxDistance: maxDistance, Points: make([]Point, 0)}
+		if i > 0 {
+			bands[i].MinDistance = req.Bands[i-1]
+		}
+	}
+
+	if len(bands) > 0 {
+		source, _, err := sr.snap(req.Origin, "origin")
+		if err != nil {
+			return IsochroneResponse{}, err
+		}
+		nodeIds, distances := dijkstraBounded[N, E](newCancellableGraph[N, E](ctx, sr.Graph), source, bands[len(bands)-1].MaxDistance)
+		if err := ctx.Err(); err != nil {
+			return IsochroneResponse{}, err
+		}
+
+		// nodes are ordered by ascending distance
+		band := 0
+		for i, nodeId := range nodeIds {
+			for distances[i] >= bands[band].MaxDistance {
+				band++
+			}
+			bands[band].Points = append(bands[band].Points, getPoint(sr.Graph.GetNode(nodeId)))
+		}
+	}
+
+	return IsochroneResponse{Bands: bands, Time: time.Since(startTime).Milliseconds()}, nil
+}
+
+// Dijkstra's algorithm from the source node, which settles all nodes with a distance less than maxDistance.
+// Returns the settled nodes ordered by the time they have been settled together with their distances.
+func dijkstraBounded[N any, E g.IWeightedHalfEdge[int]](graph g.Graph[N, E], source g.NodeId, maxDistance int) ([]g.NodeId, []int) {
+	nodeIds, distances := make([]g.NodeId, 0), make([]int, 0)
+
+	tentative := make(map[g.NodeId]int)
+	settled := make(map[g.NodeId]bool)
+
+	tentative[source] = 0
+	pq := priorityQueue{pqItem{id: source, priority: 0}}
+
+	for len(pq) > 0 {
+		current := heap.Pop(&pq).(pqItem)
+		if current.priority >= maxDistance {
+			break
+		}
+		if settled[current.id] {
+			continue
+		}
+		settled[current.id] = true
+		nodeIds = append(nodeIds, current.id)
+		distances = append(distances, current.priority)
+
+		for _, edge := range graph.GetHalfEdgesFrom(current.id) {
+			successor := edge.To()
+			newDistance := current.priority + edge.Weight()
+			if d, ok := tentative[successor]; newDistance < maxDistance && (!ok || newDistance < d) {
+				tentative[successor] = newDistance
+				heap.Push(&pq, pqItem{id: successor, priority: newDistance})
+			}
+		}
+	}
+	return nodeIds, distances
+}
+
+// FeatureCollection returns one GeoJSON MultiPoint feature per distance band.
+// Each feature has the properties min_distance and max_distance (unit meters).
+func (res IsochroneResponse) FeatureCollection() *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, band := range res.Bands {
+		multiPoint := make(orb.MultiPoint, 0, len(band.Points))
+		for _, p := range band.Points {
+			multiPoint = append(multiPoint, orb.Point{p.Lon, p.Lat})
+		}
+		feature := geojson.NewFeature(multiPoint)
+		feature.Properties["min_distance"] = band.MinDistance
+		feature.Properties["max_distance"] = band.MaxDistance
+		fc.Append(feature)
+	}
+	return fc
+}
+
+// EncodeIsochroneGeoJSON writes the IsochroneResponse as GeoJSON FeatureCollection
+func EncodeIsochroneGeoJSON(w io.Writer, res IsochroneResponse) error {
+	return json.NewEncoder(w).Encode(res.FeatureCollection())
+}
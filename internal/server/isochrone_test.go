@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProcessIsochroneRequest(t *testing.T) {
+	graph := gridGraph(8, 8)
+	sr := newTestShipRouter(graph)
+
+	origin := Point{Lat: 0, Lon: 0}
+	req := IsochroneRequest{Origin: origin, Bands: []int{250000, 500000, 1000000}}
+	if err := req.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	res, err := sr.ProcessIsochroneRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Bands) != 3 {
+		t.Fatalf("Expected 3 bands, got %d", len(res.Bands))
+	}
+	for _, band := range res.Bands {
+		for _, p := range band.Points {
+			length := mustRoute(t, sr, RouteRequest{Origin: origin, Destination: p}).Path.Length
+			if length < band.MinDistance || length >= band.MaxDistance {
+				t.Errorf("Point %v with distance %d is not in band [%d, %d)", p, length, band.MinDistance, band.MaxDistance)
+			}
+		}
+	}
+	if res.Bands[0].Points[0] != origin {
+		t.Errorf("The origin should be the first point of the first band")
+	}
+
+	// every node within the largest band is reported exactly once
+	reported := 0
+	for _, band := range res.Bands {
+		reported += len(band.Points)
+	}
+	expected := 0
+	for nodeId := 0; nodeId < graph.NodeCount(); nodeId++ {
+		if mustRoute(t, sr, RouteRequest{Origin: origin, Destination: getPoint(graph.GetNode(nodeId))}).Path.Length < 1000000 {
+			expected++
+		}
+	}
+	if reported != expected {
+		t.Errorf("Expected %d reachable nodes, got %d", expected, reported)
+	}
+
+	if fc := res.FeatureCollection(); len(fc.Features) != 3 || fc.Features[2].Properties["max_distance"] != 1000000 {
+		t.Errorf("Unexpected feature collection")
+	}
+}
+
+func TestProcessIsochroneRequestPointOnLand(t *testing.T) {
+	sr := newTestShipRouter(gridGraph(3, 3))
+	sr.MaxSnapDistance = 50000
+
+	// about 111 km away from the closest node
+	_, err := sr.ProcessIsochroneRequest(context.Background(), IsochroneRequest{Origin: Point{Lat: 1, Lon: 3}, Bands: []int{100000}})
+	assertRequestError(t, err, ErrorCodePointOnLand, "origin")
+}
+
+func TestIsochroneRequestValidation(t *testing.T) {
+	invalid := [][]int{nil, {0}, {100, 100}, {200, 100}}
+	for _, bands := range invalid {
+		if err := (IsochroneRequest{Bands: bands}).Validate(); err == nil {
+			t.Errorf("Bands %v should be invalid", bands)
+		}
+	}
+
+	// invalid requests are rejected before snapping
+	sr := newTestShipRouter(gridGraph(3, 3))
+	_, err := sr.ProcessIsochroneRequest(context.Background(), IsochroneRequest{Origin: Point{Lat: 1, Lon: 1}, Bands: []int{200, 100}})
+	assertRequestError(t, err, ErrorCodeInvalidValue, "bands")
+	_, err = sr.ProcessIsochroneRequest(context.Background(), IsochroneRequest{Origin: Point{Lat: 91, Lon: 1}, Bands: []int{100}})
+	assertRequestError(t, err, ErrorCodeInvalidCoordinates, "origin")
+}
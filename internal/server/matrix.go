@@ -0,0 +1,140 @@
+package server
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+// Limits of matrix requests
+const (
+	MaxMatrixSize    = 10000 // maximum number of entries, i.e. sources times targets
+	MaxMatrixSources = 100   // maximum number of sources, since each source requires a search on the whole graph
+)
+
+// Validate checks the size of the matrix and the coordinates of all sources and targets
+func (req MatrixRequest) Validate() error {
+	if len(req.Sources) == 0 || len(req.Sources) > MaxMatrixSources {
+		return NewRequestError(ErrorCodeInvalidValue, "sources", "number of sources %d is not in [1, %d]", len(req.Sources), MaxMatrixSources)
+	}
+	if len(req.Targets) == 0 || len(req.Sources)*len(req.Targets) > MaxMatrixSize {
+		return NewRequestError(ErrorCodeInvalidValue, "targets", "number of targets %d is not in [1, %d] for %d sources", len(req.Targets), MaxMatrixSize/len(req.Sources), len(req.Sources))
+	}
+	for i, p := range req.Sources {
+		if err := p.Validate(fmt.Sprintf("sources[%d]", i)); err != nil {
+			return err
+		}
+	}
+	for j, p := range req.Targets {
+		if err := p.Validate(fmt.Sprintf("targets[%d]", j)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProcessMatrixRequest computes the lengths of the shortest paths between all pairs of sources and targets.
+// Every point is snapped only once. For each source, a single one-to-many Dijkstra search settles all targets.
+// A *RequestError is returned if the request is invalid or a point is farther away from the graph than the maximum snapping distance.
+// The searches of different sources run in parallel.
+//
+// Note that the matrix is computed by Dijkstra's algorithm on the graph of the ShipRouter, regardless of its router type.
+func (sr ShipRouter1[N, E]) ProcessMatrixRequest(ctx context.Context, req MatrixRequest) (MatrixResponse, error) {
+	if err := req.Validate(); err != nil {
+		return MatrixResponse{}, err
+	}
+	startTime := time.Now()
+
+	var err error
+	sources := make([]g.NodeId, len(req.Sources))
+	for i, p := range req.Sources {
+		if sources[i], _, err = sr.snap(p, fmt.Sprintf("sources[%d]", i)); err != nil {
+			return MatrixResponse{}, err
+		}
+	}
+	targets := make([]g.NodeId, len(req.Targets))
+	for j, p := range req.Targets {
+		if targets[j], _, err = sr.snap(p, fmt.Sprintf("targets[%d]", j)); err != nil {
+			return MatrixResponse{}, err
+		}
+	}
+
+	graph := newCancellableGraph[N, E](ctx, sr.Graph)
+	res := MatrixResponse{Lengths: make([][]int, len(sources)), Exists: make([][]bool, len(sources))}
+
+	// limit the number of concurrent searches, since each search allocates memory proportional to the node count
+	semaphore := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	wg.Add(len(sources))
+	for i, source := range sources {
+		go func(i int, source g.NodeId) {
+			semaphore <- struct{}{}
+			res.Lengths[i] = dijkstraOneToMany[N, E](graph, source, targets)
+			<-semaphore
+
+			res.Exists[i] = make([]bool, len(targets))
+			for j, length := range res.Lengths[i] {
+				res.Exists[i][j] = length >= 0
+			}
+			wg.Done()
+		}(i, source)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return MatrixResponse{}, err
+	}
+
+	res.Time = time.Since(startTime).Milliseconds()
+	return res, nil
+}
+
+// Dijkstra's algorithm from the source node, which terminates as soon as all target nodes have been settled.
+// Returns the length of the shortest path to each target and -1 iff the target is not reachable.
+func dijkstraOneToMany[N any, E g.IWeightedHalfEdge[int]](graph g.Graph[N, E], source g.NodeId, targets []g.NodeId) []int {
+	// the same node may occur multiple times in the list of targets
+	unsettledTargets := make(map[g.NodeId]bool)
+	for _, target := range targets {
+		unsettledTargets[target] = true
+	}
+
+	distances := make([]int, graph.NodeCount())
+	for i := range distances {
+		distances[i] = -1
+	}
+	settled := make([]bool, graph.NodeCount())
+
+	distances[source] = 0
+	pq := priorityQueue{pqItem{id: source, priority: 0}}
+
+	for len(pq) > 0 && len(unsettledTargets) > 0 {
+		current := heap.Pop(&pq).(pqItem)
+		if settled[current.id] {
+			continue
+		}
+		settled[current.id] = true
+		delete(unsettledTargets, current.id)
+
+		for _, edge := range graph.GetHalfEdgesFrom(current.id) {
+			successor := edge.To()
+			if newDistance := current.priority + edge.Weight(); distances[successor] < 0 || newDistance < distances[successor] {
+				distances[successor] = newDistance
+				heap.Push(&pq, pqItem{id: successor, priority: newDistance})
+			}
+		}
+	}
+
+	lengths := make([]int, len(targets))
+	for j, target := range targets {
+		if settled[target] {
+			lengths[j] = distances[target]
+		} else {
+			lengths[j] = -1
+		}
+	}
+	return lengths
+}
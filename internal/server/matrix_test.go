@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	sp "github.com/dmholtz/graffiti/algorithms/shortest_path"
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+func TestProcessMatrixRequest(t *testing.T) {
+	graph := gridGraph(6, 6)
+	sr := newTestShipRouter(graph)
+
+	req := MatrixRequest{
+		Sources: []Point{{Lat: 0, Lon: 0}, {Lat: 5, Lon: 5}, {Lat: 2, Lon: 3}},
+		Targets: []Point{{Lat: 5, Lon: 0}, {Lat: 0, Lon: 0}, {Lat: 3, Lon: 4}, {Lat: 5, Lon: 0}},
+	}
+	res, err := sr.ProcessMatrixRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Lengths) != len(req.Sources) {
+		t.Fatalf("Expected %d rows, got %d", len(req.Sources), len(res.Lengths))
+	}
+	for i, source := range req.Sources {
+		for j, target := range req.Targets {
+			want := mustRoute(t, sr, RouteRequest{Origin: source, Destination: target})
+			if !res.Exists[i][j] || res.Lengths[i][j] != want.Path.Length {
+				t.Errorf("Matrix entry (%d, %d) = %d, expected %d", i, j, res.Lengths[i][j], want.Path.Length)
+			}
+		}
+	}
+}
+
+func TestProcessMatrixRequestPointOnLand(t *testing.T) {
+	sr := newTestShipRouter(gridGraph(3, 3))
+	sr.MaxSnapDistance = 50000
+
+	// about 111 km away from the closest node
+	req := MatrixRequest{Sources: []Point{{Lat: 0, Lon: 0}}, Targets: []Point{{Lat: 2, Lon: 2}, {Lat: 1, Lon: 3}}}
+	_, err := sr.ProcessMatrixRequest(context.Background(), req)
+	assertRequestError(t, err, ErrorCodePointOnLand, "targets[1]")
+
+	req.Sources, req.Targets = req.Targets, req.Sources
+	_, err = sr.ProcessMatrixRequest(context.Background(), req)
+	assertRequestError(t, err, ErrorCodePointOnLand, "sources[1]")
+}
+
+func TestMatrixRequestValidate(t *testing.T) {
+	sr := newTestShipRouter(gridGraph(3, 3))
+	points := func(n int) []Point {
+		return make([]Point, n)
+	}
+	invalid := []struct {
+		req         MatrixRequest
+		code, field string
+	}{
+		{MatrixRequest{Targets: points(1)}, ErrorCodeInvalidValue, "sources"},
+		{MatrixRequest{Sources: points(1)}, ErrorCodeInvalidValue, "targets"},
+		{MatrixRequest{Sources: []Point{{Lat: math.NaN()}}, Targets: points(1)}, ErrorCodeInvalidCoordinates, "sources[0]"},
+		{MatrixRequest{Sources: points(1), Targets: []Point{{}, {Lon: 181}}}, ErrorCodeInvalidCoordinates, "targets[1]"},
+	}
+	for _, test := range invalid {
+		_, err := sr.ProcessMatrixRequest(context.Background(), test.req)
+		assertRequestError(t, err, test.code, test.field)
+	}
+	if err := (MatrixRequest{Sources: points(MaxMatrixSources + 1), Targets: points(1)}).Validate(); err == nil {
+		t.Errorf("Expected too many sources to be rejected")
+	}
+	if err := (MatrixRequest{Sources: points(MaxMatrixSources), Targets: points(MaxMatrixSize/MaxMatrixSources + 1)}).Validate(); err == nil {
+		t.Errorf("Expected a matrix with more than %d entries to be rejected", MaxMatrixSize)
+	}
+	if err := (MatrixRequest{Sources: points(MaxMatrixSources), Targets: points(MaxMatrixSize / MaxMatrixSources)}).Validate(); err != nil {
+		t.Errorf("Matrix of the maximum size should be valid: %v", err)
+	}
+}
+
+func TestOneToManyUnreachable(t *testing.T) {
+	alg := &g.AdjacencyListGraph[g.GeoPoint, g.WeightedHalfEdge[int]]{}
+	for i := 0; i < 3; i++ {
+		alg.AppendNode(g.GeoPoint{Lat: 0, Lon: float64(i)})
+	}
+	alg.InsertHalfEdge(0, g.WeightedHalfEdge[int]{To_: 1, Weight_: 7})
+
+	lengths := dijkstraOneToMany[g.GeoPoint, g.WeightedHalfEdge[int]](alg, 0, []g.NodeId{1, 2, 0})
+	if lengths[0] != 7 || lengths[1] != -1 || lengths[2] != 0 {
+		t.Errorf("Unexpected lengths %v", lengths)
+	}
+
+	// compare with graffiti's Dijkstra
+	router := sp.DijkstraRouter[g.GeoPoint, g.WeightedHalfEdge[int], int]{Graph: alg}
+	if res := router.Route(0, 2, false); res.Length != lengths[1] {
+		t.Errorf("graffiti reports length %d, one-to-many reports %d", res.Length, lengths[1])
+	}
+}
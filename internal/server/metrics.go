@@ -0,0 +1,224 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Upper bounds of the histogram buckets
+var (
+	latencyBuckets      = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60} // unit seconds
+	searchSpaceBuckets  = []float64{100, 1000, 10000, 100000, 1000000, 10000000}                     // unit settled nodes
+	snapDistanceBuckets = []float64{100, 500, 1000, 5000, 10000, 50000, 100000, 500000}              // unit meters
+)
+
+// Metrics collects the performance of the ship routers and writes it in the Prometheus text exposition format.
+// All methods are safe for concurrent use. Observations on a nil *Metrics are discarded.
+type Metrics struct {
+	mutex        sync.Mutex
+	requests     *metricVec
+	errors       *metricVec
+	latency      *metricVec
+	searchSpace  *metricVec
+	snapDistance *metricVec
+	unreachable  *metricVec
+	cacheLookups *metricVec
+}
+
+// Create a new collection of metrics without any observations
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:     newCounterVec("ship_routing_requests_total", "Number of processed requests.", "router", "endpoint"),
+		errors:       newCounterVec("ship_routing_errors_total", "Number of requests answered with an error status code.", "router", "endpoint", "status"),
+		latency:      newHistogramVec("ship_routing_request_duration_seconds", "Time required to process a request.", latencyBuckets, "router", "endpoint"),
+		searchSpace:  newHistogramVec("ship_routing_search_space_nodes", "Number of nodes settled by the router per route request.", searchSpaceBuckets, "router"),
+		snapDistance: newHistogramVec("ship_routing_snap_distance_meters", "Distance between a requested point and the node it has been snapped to.", snapDistanceBuckets, "router"),
+		unreachable:  newCounterVec("ship_routing_unreachable_routes_total", "Number of route requests whose destination is not reachable.", "router"),
+		cacheLookups: newCounterVec("ship_routing_cache_lookups_total", "Number of shortest path lookups in the route cache.", "router", "result"),
+	}
+}
+
+// ObserveRequest records a processed request of an endpoint and the time required to process it
+func (m *Metrics) ObserveRequest(router, endpoint string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.observe(m.requests, 1, router, endpoint)
+	m.observe(m.latency, duration.Seconds(), router, endpoint)
+}
+
+// ObserveError records a request of an endpoint that has been answered with an error status code
+func (m *Metrics) ObserveError(router, endpoint string, status int) {
+	if m == nil {
+		return
+	}
+	m.observe(m.errors, 1, router, endpoint, strconv.Itoa(status))
+}
+
+// ObserveSearchSpace records the number of nodes settled to answer a route request
+func (m *Metrics) ObserveSearchSpace(router string, settledNodes int) {
+	if m == nil {
+		return
+	}
+	m.observe(m.searchSpace, float64(settledNodes), router)
+}
+
+// ObserveSnapDistance records the distance (unit meters) between a requested point and its snapped node
+func (m *Metrics) ObserveSnapDistance(router string, distance int) {
+	if m == nil {
+		return
+	}
+	m.observe(m.snapDistance, float64(distance), router)
+}
+
+// ObserveUnreachable records a route request whose destination is not reachable
+func (m *Metrics) ObserveUnreachable(router string) {
+	if m == nil {
+		return
+	}
+	m.observe(m.unreachable, 1, router)
+}
+
+// ObserveCacheLookup records a lookup of a shortest path in the route cache
+func (m *Metrics) ObserveCacheLookup(router string, hit bool) {
+	if m == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.observe(m.cacheLookups, 1, router, result)
+}
+
+func (m *Metrics) observe(vec *metricVec, value float64, labelValues ...string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	vec.observe(value, labelValues)
+}
+
+// WritePrometheus writes all metrics in the Prometheus text exposition format (version 0.0.4)
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, vec := range []*metricVec{m.requests, m.errors, m.latency, m.searchSpace, m.snapDistance, m.unreachable, m.cacheLookups} {
+		vec.write(bw)
+	}
+	return bw.Flush()
+}
+
+// A metricVec is a counter or histogram partitioned by the values of its labels
+type metricVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64 // nil iff the metric is a counter
+	series  map[string]*series
+}
+
+// Observations of a metricVec for one combination of label values
+type series struct {
+	labelValues  []string
+	sum          float64
+	count        uint64
+	bucketCounts []uint64 // non-cumulative counts of the histogram buckets
+}
+
+func newCounterVec(name, help string, labels ...string) *metricVec {
+	return &metricVec{name: name, help: help, labels: labels, series: make(map[string]*series)}
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *metricVec {
+	return &metricVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*series)}
+}
+
+func (vec *metricVec) isHistogram() bool {
+	return vec.buckets != nil
+}
+
+func (vec *metricVec) observe(value float64, labelValues []string) {
+	if len(labelValues) != len(vec.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", vec.name, len(vec.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := vec.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string{}, labelValues...), bucketCounts: make([]uint64, len(vec.buckets))}
+		vec.series[key] = s
+	}
+	s.sum += value
+	s.count++
+	if vec.isHistogram() {
+		// values greater than the largest upper bound are only contained in the +Inf bucket
+		if i := sort.SearchFloat64s(vec.buckets, value); i < len(vec.buckets) {
+			s.bucketCounts[i]++
+		}
+	}
+}
+
+func (vec *metricVec) write(w *bufio.Writer) {
+	metricType := "counter"
+	if vec.isHistogram() {
+		metricType = "histogram"
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n", vec.name, vec.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", vec.name, metricType)
+
+	// the output is ordered by label values
+	keys := make([]string, 0, len(vec.series))
+	for key := range vec.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	bucketLabels := append(append([]string{}, vec.labels...), "le")
+	for _, key := range keys {
+		s := vec.series[key]
+		labels := formatLabels(vec.labels, s.labelValues)
+		if !vec.isHistogram() {
+			fmt.Fprintf(w, "%s%s %s\n", vec.name, labels, formatValue(s.sum))
+			continue
+		}
+		bucketLabelValues := append(append([]string{}, s.labelValues...), "")
+		cumulativeCount := uint64(0)
+		for i, upperBound := range vec.buckets {
+			cumulativeCount += s.bucketCounts[i]
+			bucketLabelValues[len(s.labelValues)] = formatValue(upperBound)
+			fmt.Fprintf(w, "%s_bucket%s %d\n", vec.name, formatLabels(bucketLabels, bucketLabelValues), cumulativeCount)
+		}
+		bucketLabelValues[len(s.labelValues)] = "+Inf"
+		fmt.Fprintf(w, "%s_bucket%s %d\n", vec.name, formatLabels(bucketLabels, bucketLabelValues), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", vec.name, labels, formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", vec.name, labels, s.count)
+	}
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + labelValueEscaper.Replace(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Label values escape backslash, double-quote and line feed
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
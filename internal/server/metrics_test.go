@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeMetrics(t *testing.T, m *Metrics) string {
+	var sb strings.Builder
+	if err := m.WritePrometheus(&sb); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+func assertContainsLine(t *testing.T, text, line string) {
+	for _, l := range strings.Split(text, "\n") {
+		if l == line {
+			return
+		}
+	}
+	t.Errorf("Missing line '%s' in:\n%s", line, text)
+}
+
+func TestMetricsCounter(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveRequest("dijkstra", "route", 30*time.Millisecond)
+	m.ObserveRequest("dijkstra", "route", 2*time.Second)
+	m.ObserveError("a-star", "matrix", 503)
+
+	text := writeMetrics(t, m)
+	assertContainsLine(t, text, "# TYPE ship_routing_requests_total counter")
+	assertContainsLine(t, text, `ship_routing_requests_total{router="dijkstra",endpoint="route"} 2`)
+	assertContainsLine(t, text, `ship_routing_errors_total{router="a-star",endpoint="matrix",status="503"} 1`)
+}
+
+func TestMetricsHistogram(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveRequest("dijkstra", "route", 30*time.Millisecond)
+	m.ObserveRequest("dijkstra", "route", 2*time.Second)
+	m.ObserveRequest("dijkstra", "route", 2*time.Minute)
+
+	text := writeMetrics(t, m)
+	assertContainsLine(t, text, "# TYPE ship_routing_request_duration_seconds histogram")
+	// buckets are cumulative
+	assertContainsLine(t, text, `ship_routing_request_duration_seconds_bucket{router="dijkstra",endpoint="route",le="0.025"} 0`)
+	assertContainsLine(t, text, `ship_routing_request_duration_seconds_bucket{router="dijkstra",endpoint="route",le="0.05"} 1`)
+	assertContainsLine(t, text, `ship_routing_request_duration_seconds_bucket{router="dijkstra",endpoint="route",le="2.5"} 2`)
+	assertContainsLine(t, text, `ship_routing_request_duration_seconds_bucket{router="dijkstra",endpoint="route",le="60"} 2`)
+	assertContainsLine(t, text, `ship_routing_request_duration_seconds_bucket{router="dijkstra",endpoint="route",le="+Inf"} 3`)
+	assertContainsLine(t, text, `ship_routing_request_duration_seconds_sum{router="dijkstra",endpoint="route"} 122.03`)
+	assertContainsLine(t, text, `ship_routing_request_duration_seconds_count{router="dijkstra",endpoint="route"} 3`)
+}
+
+func TestMetricsLabelEscaping(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveUnreachable("a\"b\\c\nd")
+	assertContainsLine(t, writeMetrics(t, m), `ship_routing_unreachable_routes_total{router="a\"b\\c\nd"} 1`)
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	// observations are discarded
+	m.ObserveRequest("dijkstra", "route", time.Second)
+	m.ObserveSnapDistance("dijkstra", 100)
+}
+
+func TestShipRouterMetrics(t *testing.T) {
+	graph := gridGraph(3, 3)
+	sr := newTestShipRouter(graph)
+	sr.Id = "dijkstra"
+	sr.Metrics = NewMetrics()
+
+	// the origin is snapped to node (0, 0) in a distance of about 11 km
+	if _, err := sr.ProcessRequest(context.Background(), RouteRequest{Origin: Point{Lat: -0.1, Lon: 0}, Destination: Point{Lat: 2, Lon: 2}}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	text := writeMetrics(t, sr.Metrics)
+	assertContainsLine(t, text, `ship_routing_snap_distance_meters_count{router="dijkstra"} 2`)
+	assertContainsLine(t, text, `ship_routing_snap_distance_meters_bucket{router="dijkstra",le="10000"} 1`)
+	assertContainsLine(t, text, `ship_routing_snap_distance_meters_bucket{router="dijkstra",le="50000"} 2`)
+	assertContainsLine(t, text, `ship_routing_search_space_nodes_count{router="dijkstra"} 1`)
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	g "github.com/dmholtz/graffiti/graph"
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+)
+
+// NodeIndex is a static k-d tree over the unit vectors of the nodes of a graph.
+// It answers nearest neighbor queries in logarithmic time on average.
+//
+// The chord distance between two unit vectors grows monotonically with their great circle distance.
+// Therefore, the nearest node in terms of the chord distance is also the nearest node in terms of the haversine distance.
+type NodeIndex struct {
+	ids     []g.NodeId    // node ids in k-d tree order
+	vectors []geo.Vector3 // unit vectors in k-d tree order
+}
+
+// Build a NodeIndex containing every node of the graph
+func NewNodeIndex[N IGeoPoint, E g.IHalfEdge](graph g.Graph[N, E]) *NodeIndex {
+	ni := NodeIndex{ids: make([]g.NodeId, graph.NodeCount()), vectors: make([]geo.Vector3, graph.NodeCount())}
+	for nodeId := 0; nodeId < graph.NodeCount(); nodeId++ {
+		p := getPoint(graph.GetNode(nodeId))
+		ni.ids[nodeId] = nodeId
+		ni.vectors[nodeId] = geo.NewPoint(p.Lat, p.Lon).UnitVector()
+	}
+	ni.build(0, len(ni.ids), 0)
+	return &ni
+}
+
+// Size returns the number of indexed nodes
+func (ni *NodeIndex) Size() int {
+	return len(ni.ids)
+}
+
+// Nearest returns the id of the node which is closest to the point p.
+// ok is false iff the index is empty.
+func (ni *NodeIndex) Nearest(p Point) (nodeId g.NodeId, ok bool) {
+	if ni.Size() == 0 {
+		return -1, false
+	}
+	query := geo.NewPoint(p.Lat, p.Lon).UnitVector()
+	best, bestDist := -1, 5.0 // squared chord distances are at most 4
+	ni.nearest(query, 0, len(ni.ids), 0, &best, &bestDist)
+	return ni.ids[best], true
+}
+
+// The median of the range [lo, hi) is the root of the respective subtree.
+// Its left subtree is stored in [lo, mid) and its right subtree in [mid+1, hi).
+func (ni *NodeIndex) build(lo, hi, depth int) {
+	if hi-lo < 2 {
+		return
+	}
+	mid := (lo + hi) / 2
+	ni.selectNth(lo, hi, mid, depth%3)
+	ni.build(lo, mid, depth+1)
+	ni.build(mid+1, hi, depth+1)
+}
+
+// Partially sorts the range [lo, hi) along the axis such that the n-th element is in its final position (quickselect).
+func (ni *NodeIndex) selectNth(lo, hi, n, axis int) {
+	for hi-lo > 1 {
+		// median of three as pivot
+		mid := (lo + hi) / 2
+		if ni.vectors[mid][axis] < ni.vectors[lo][axis] {
+			ni.swap(mid, lo)
+		}
+		if ni.vectors[hi-1][axis] < ni.vectors[lo][axis] {
+			ni.swap(hi-1, lo)
+		}
+		if ni.vectors[mid][axis] < ni.vectors[hi-1][axis] {
+			ni.swap(mid, hi-1)
+		}
+		pivot := ni.vectors[hi-1][axis]
+
+		// three-way partition: [lo, lt) < pivot, [lt, gt) == pivot, [gt, hi) > pivot
+		// Grid graphs contain many nodes with identical coordinates along an axis, which would degrade a two-way partition.
+		lt, i, gt := lo, lo, hi
+		for i < gt {
+			if v := ni.vectors[i][axis]; v < pivot {
+				ni.swap(lt, i)
+				lt++
+				i++
+			} else if v > pivot {
+				gt--
+				ni.swap(i, gt)
+			} else {
+				i++
+			}
+		}
+
+		if n < lt {
+			hi = lt
+		} else if n >= gt {
+			lo = gt
+		} else {
+			return
+		}
+	}
+}
+
+func (ni *NodeIndex) swap(i, j int) {
+	ni.ids[i], ni.ids[j] = ni.ids[j], ni.ids[i]
+	ni.vectors[i], ni.vectors[j] = ni.vectors[j], ni.vectors[i]
+}
+
+func (ni *NodeIndex) nearest(query geo.Vector3, lo, hi, depth int, best *int, bestDist *float64) {
+	if lo >= hi {
+		return
+	}
+	mid := (lo + hi) / 2
+	if dist := query.SquaredDistance(ni.vectors[mid]); dist < *bestDist {
+		*best = mid
+		*bestDist = dist
+	}
+
+	axis := depth % 3
+	delta := query[axis] - ni.vectors[mid][axis]
+	// descend into the subtree containing the query first
+	if delta < 0 {
+		ni.nearest(query, lo, mid, depth+1, best, bestDist)
+		if delta*delta < *bestDist {
+			ni.nearest(query, mid+1, hi, depth+1, best, bestDist)
+		}
+	} else {
+		ni.nearest(query, mid+1, hi, depth+1, best, bestDist)
+		if delta*delta < *bestDist {
+			ni.nearest(query, lo, mid, depth+1, best, bestDist)
+		}
+	}
+}
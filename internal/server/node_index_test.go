@@ -0,0 +1,84 @@
+package server
+
+import (
+	"math/rand"
+	"testing"
+
+	heur "github.com/dmholtz/graffiti/examples/heuristics"
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+const numberOfRandomQueries = 1000
+
+func randomPoint(rnd *rand.Rand) Point {
+	return Point{Lat: rnd.Float64()*180 - 90, Lon: rnd.Float64()*360 - 180}
+}
+
+func randomGraph(rnd *rand.Rand, nodeCount int) *g.AdjacencyListGraph[g.GeoPoint, g.WeightedHalfEdge[int]] {
+	alg := &g.AdjacencyListGraph[g.GeoPoint, g.WeightedHalfEdge[int]]{}
+	for i := 0; i < nodeCount; i++ {
+		p := randomPoint(rnd)
+		alg.AppendNode(g.GeoPoint{Lat: p.Lat, Lon: p.Lon})
+	}
+	return alg
+}
+
+// Compare the nearest node with the result of a linear scan.
+// Different nodes are only accepted if they have the same distance to the query point.
+func assertSameAsLinearScan[N IGeoPoint, E g.IHalfEdge](t *testing.T, graph g.Graph[N, E], index *NodeIndex, p Point) {
+	got, _ := index.Nearest(p)
+	want := findClosestNode(graph, p)
+	if got != want {
+		query := g.GeoPoint{Lat: p.Lat, Lon: p.Lon}
+		gotPoint, wantPoint := getPoint(graph.GetNode(got)), getPoint(graph.GetNode(want))
+		gotDist := heur.Haversine(g.GeoPoint{Lat: gotPoint.Lat, Lon: gotPoint.Lon}, query)
+		wantDist := heur.Haversine(g.GeoPoint{Lat: wantPoint.Lat, Lon: wantPoint.Lon}, query)
+		if gotDist != wantDist {
+			t.Errorf("Nearest(%v) = %d (distance %d), linear scan found %d (distance %d)", p, got, gotDist, want, wantDist)
+		}
+	}
+}
+
+func TestNodeIndexRandomGraph(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	graph := randomGraph(rnd, 10000)
+	index := NewNodeIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph)
+
+	if index.Size() != graph.NodeCount() {
+		t.Fatalf("Index contains %d nodes, expected %d", index.Size(), graph.NodeCount())
+	}
+	for i := 0; i < numberOfRandomQueries; i++ {
+		assertSameAsLinearScan[g.GeoPoint, g.WeightedHalfEdge[int]](t, graph, index, randomPoint(rnd))
+	}
+}
+
+func TestNodeIndexGrid(t *testing.T) {
+	// regular grid with many identical coordinates and nodes on the antimeridian
+	graph := &g.AdjacencyListGraph[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int]]{}
+	for lat := -80; lat <= 80; lat += 5 {
+		for lon := -180; lon < 180; lon += 5 {
+			graph.AppendNode(g.PartGeoPoint{GeoPoint: g.GeoPoint{Lat: float64(lat), Lon: float64(lon)}})
+		}
+	}
+	index := NewNodeIndex[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int]](graph)
+
+	rnd := rand.New(rand.NewSource(2))
+	for i := 0; i < numberOfRandomQueries; i++ {
+		assertSameAsLinearScan[g.PartGeoPoint, g.LargeFlaggedHalfEdge[int]](t, graph, index, randomPoint(rnd))
+	}
+
+	// nodes are found exactly
+	for nodeId := 0; nodeId < graph.NodeCount(); nodeId++ {
+		if got, _ := index.Nearest(getPoint(graph.GetNode(nodeId))); got != nodeId {
+			t.Errorf("Nearest(node %d) = %d", nodeId, got)
+		}
+	}
+}
+
+func TestNodeIndexEmpty(t *testing.T) {
+	graph := &g.AdjacencyListGraph[g.GeoPoint, g.WeightedHalfEdge[int]]{}
+	index := NewNodeIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph)
+	if got, ok := index.Nearest(Point{Lat: 0, Lon: 0}); ok {
+		t.Errorf("Nearest on empty index = %d, expected no node", got)
+	}
+}
@@ -0,0 +1,19 @@
+package server
+
+// Extend returns the path starting at the requested origin and ending at the requested destination.
+// The requested points are only added if they differ from the snapped points, which are the first and last waypoint of the path.
+// The length increases by the snapping distances.
+func (path Path) Extend(origin, destination SnappedPoint) Path {
+	waypoints := make([]Point, 0, len(path.Waypoints)+2)
+	length := path.Length
+	if origin.Requested != origin.Snapped {
+		waypoints = append(waypoints, origin.Requested)
+		length += origin.Distance
+	}
+	waypoints = append(waypoints, path.Waypoints...)
+	if destination.Requested != destination.Snapped {
+		waypoints = append(waypoints, destination.Requested)
+		length += destination.Distance
+	}
+	return Path{Waypoints: waypoints, Length: length}
+}
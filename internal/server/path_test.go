@@ -0,0 +1,21 @@
+package server
+
+import "testing"
+
+func TestPathExtend(t *testing.T) {
+	path := Path{Waypoints: []Point{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 1}}, Length: 111000}
+	origin := SnappedPoint{Requested: Point{Lat: 0.1, Lon: 0}, Snapped: Point{Lat: 0, Lon: 0}, Distance: 11000}
+	destination := SnappedPoint{Requested: Point{Lat: 0, Lon: 1}, Snapped: Point{Lat: 0, Lon: 1}, Distance: 0}
+
+	extended := path.Extend(origin, destination)
+	// the destination coincides with the last waypoint and is not appended
+	if len(extended.Waypoints) != 3 || extended.Waypoints[0] != origin.Requested || extended.Waypoints[2] != destination.Snapped {
+		t.Errorf("Unexpected waypoints %v", extended.Waypoints)
+	}
+	if extended.Length != 122000 {
+		t.Errorf("Extended length is %d, expected 122000", extended.Length)
+	}
+	if len(path.Waypoints) != 2 {
+		t.Errorf("The original path must not be modified")
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import g "github.com/dmholtz/graffiti/graph"
+
+// Atomic element of the priority queue used by the graph searches of this package
+type pqItem struct {
+	id       g.NodeId
+	priority int
+}
+
+// A min-heap of pqItem values, which implements heap.Interface (https://pkg.go.dev/container/heap)
+//
+// Unlike the priority queues of graffiti, decreasing the priority of a node is implemented by pushing the node again (lazy deletion).
+// Searches must therefore skip items of nodes that have already been settled.
+type priorityQueue []pqItem
+
+// Len implements heap.Interface
+func (pq priorityQueue) Len() int {
+	return len(pq)
+}
+
+// Less implements heap.Interface
+func (pq priorityQueue) Less(i, j int) bool {
+	return pq[i].priority < pq[j].priority
+}
+
+// Swap implements heap.Interface
+func (pq priorityQueue) Swap(i, j int) {
+	pq[i], pq[j] = pq[j], pq[i]
+}
+
+// Push implements heap.Interface
+func (pq *priorityQueue) Push(item interface{}) {
+	*pq = append(*pq, item.(pqItem))
+}
+
+// Pop implements heap.Interface
+func (pq *priorityQueue) Pop() interface{} {
+	old := *pq
+	n := len(old)
+	item := old[n-1]
+	*pq = old[0 : n-1]
+	return item
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+// RouteCache is a LRU cache for the shortest paths between snapped nodes.
+// Since snapping maps many points to the same node, shortest paths are cached per pair of nodes rather than per request.
+// Entries expire after the TTL. All methods are safe for concurrent use.
+type RouteCache struct {
+	mutex    sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[routeCacheKey]*list.Element
+	lru      *list.List // most recently used entry at the front
+	now      func() time.Time
+}
+
+type routeCacheKey struct {
+	router string
+	source g.NodeId
+	target g.NodeId
+}
+
+type routeCacheEntry struct {
+	key     routeCacheKey
+	length  int
+	path    []g.NodeId
+	expires time.Time
+}
+
+// Create a RouteCache holding at most capacity shortest paths. Entries never expire iff ttl is not positive.
+func NewRouteCache(capacity int, ttl time.Duration) *RouteCache {
+	return &RouteCache{capacity: capacity, ttl: ttl, entries: make(map[routeCacheKey]*list.Element), lru: list.New(), now: time.Now}
+}
+
+// Get returns the length and the path of the cached shortest path from source to target on the router.
+// The length is -1 iff the target is not reachable. The last return value is false iff the cache does not contain the path.
+// The returned path must not be modified.
+func (c *RouteCache) Get(router string, source, target g.NodeId) (int, []g.NodeId, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[routeCacheKey{router: router, source: source, target: target}]
+	if !ok {
+		return 0, nil, false
+	}
+	entry := element.Value.(*routeCacheEntry)
+	if c.ttl > 0 && c.now().After(entry.expires) {
+		c.remove(element)
+		return 0, nil, false
+	}
+	c.lru.MoveToFront(element)
+	return entry.length, entry.path, true
+}
+
+// Put inserts the shortest path from source to target on the router and evicts the least recently used path if the cache is full.
+// The path must not be modified afterwards.
+func (c *RouteCache) Put(router string, source, target g.NodeId, length int, path []g.NodeId) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := routeCacheKey{router: router, source: source, target: target}
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	for c.lru.Len() >= c.capacity {
+		c.remove(c.lru.Back())
+	}
+	entry := &routeCacheEntry{key: key, length: length, path: path, expires: c.now().Add(c.ttl)}
+	c.entries[key] = c.lru.PushFront(entry)
+}
+
+// Len returns the number of cached paths including expired ones, which have not been removed yet
+func (c *RouteCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.lru.Len()
+}
+
+func (c *RouteCache) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*routeCacheEntry).key)
+}
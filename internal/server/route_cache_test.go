@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+func TestRouteCacheEviction(t *testing.T) {
+	cache := NewRouteCache(2, 0)
+	cache.Put("dijkstra", 0, 1, 10, []g.NodeId{0, 1})
+	cache.Put("dijkstra", 0, 2, 20, []g.NodeId{0, 2})
+
+	// touch (0, 1) such that (0, 2) is the least recently used path
+	if length, _, ok := cache.Get("dijkstra", 0, 1); !ok || length != 10 {
+		t.Errorf("Expected cached path of length 10, got %d (hit=%t)", length, ok)
+	}
+	cache.Put("dijkstra", 0, 3, -1, nil)
+
+	if _, _, ok := cache.Get("dijkstra", 0, 2); ok {
+		t.Errorf("Least recently used path should have been evicted")
+	}
+	if length, _, ok := cache.Get("dijkstra", 0, 3); !ok || length != -1 {
+		t.Errorf("Unreachable targets should be cached")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Cache contains %d paths, expected 2", cache.Len())
+	}
+}
+
+func TestRouteCacheKey(t *testing.T) {
+	cache := NewRouteCache(10, 0)
+	cache.Put("dijkstra", 0, 1, 10, []g.NodeId{0, 1})
+	if _, _, ok := cache.Get("a-star", 0, 1); ok {
+		t.Errorf("Paths of different routers must not be shared")
+	}
+	if _, _, ok := cache.Get("dijkstra", 1, 0); ok {
+		t.Errorf("Paths are directed")
+	}
+}
+
+func TestRouteCacheTTL(t *testing.T) {
+	now := time.Unix(0, 0)
+	cache := NewRouteCache(10, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.Put("dijkstra", 0, 1, 10, []g.NodeId{0, 1})
+	now = now.Add(59 * time.Second)
+	if _, _, ok := cache.Get("dijkstra", 0, 1); !ok {
+		t.Errorf("Path should not have expired yet")
+	}
+	now = now.Add(2 * time.Second)
+	if _, _, ok := cache.Get("dijkstra", 0, 1); ok {
+		t.Errorf("Path should have expired")
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Expired path should have been removed")
+	}
+}
+
+func TestRouteCacheConcurrency(t *testing.T) {
+	cache := NewRouteCache(16, time.Minute)
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				cache.Put("dijkstra", worker, i%32, i, nil)
+				cache.Get("dijkstra", worker, (i+1)%32)
+			}
+		}(worker)
+	}
+	wg.Wait()
+	if cache.Len() > 16 {
+		t.Errorf("Cache exceeds its capacity: %d", cache.Len())
+	}
+}
+
+func TestProcessRequestWithCache(t *testing.T) {
+	graph := gridGraph(5, 5)
+	sr := newTestShipRouter(graph)
+	sr.Id = "dijkstra"
+	sr.Cache = NewRouteCache(10, 0)
+
+	req := RouteRequest{Origin: Point{Lat: 0, Lon: 0}, Destination: Point{Lat: 4, Lon: 4}}
+	first := mustRoute(t, sr, req)
+	if first.CacheHit {
+		t.Errorf("First request cannot be a cache hit")
+	}
+
+	// a different point that is snapped to the same nodes
+	second := mustRoute(t, sr, RouteRequest{Origin: Point{Lat: 0.1, Lon: -0.1}, Destination: Point{Lat: 4.1, Lon: 3.9}})
+	if !second.CacheHit || !second.Legs[0].CacheHit {
+		t.Errorf("Second request should be served from the cache")
+	}
+	if second.Path.Length != first.Path.Length || len(second.Path.Waypoints) != len(first.Path.Waypoints) {
+		t.Errorf("Cached route %+v differs from computed route %+v", second.Path, first.Path)
+	}
+
+	// the search space is not cached
+	res, err := sr.ProcessRequest(context.Background(), req, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CacheHit || len(res.SearchSpace) == 0 {
+		t.Errorf("Requests for the search space must bypass the cache")
+	}
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// RTZ is the route exchange format of IEC 61174 used by ECDIS systems.
+// The implementation covers the subset of RTZ 1.0 required to exchange a computed route.
+
+const rtzNamespace = "http://www.cirm.org/RTZ/1/0"
+
+// Legs are great circle segments
+const rtzOrthodrome = "Orthodrome"
+
+type RTZRoute struct {
+	XMLName   xml.Name      `xml:"http://www.cirm.org/RTZ/1/0 route"`
+	Version   string        `xml:"version,attr"`
+	Info      RTZRouteInfo  `xml:"routeInfo"`
+	Waypoints []RTZWaypoint `xml:"waypoints>waypoint"`
+	Schedules *RTZSchedules `xml:"schedules,omitempty"`
+}
+
+type RTZRouteInfo struct {
+	RouteName string `xml:"routeName,attr"`
+}
+
+type RTZWaypoint struct {
+	Id       int         `xml:"id,attr"`
+	Name     string      `xml:"name,attr,omitempty"`
+	Position RTZPosition `xml:"position"`
+	// geometry of the leg from the previous waypoint to this waypoint
+	Leg *RTZLeg `xml:"leg,omitempty"`
+}
+
+type RTZPosition struct {
+	Lat float64 `xml:"lat,attr"`
+	Lon float64 `xml:"lon,attr"`
+}
+
+type RTZLeg struct {
+	GeometryType string `xml:"geometryType,attr,omitempty"`
+}
+
+type RTZSchedules struct {
+	Schedules []RTZSchedule `xml:"schedule"`
+}
+
+type RTZSchedule struct {
+	Id   int    `xml:"id,attr"`
+	Name string `xml:"name,attr,omitempty"`
+	// the element name 'sheduleElement' is spelled as defined by the RTZ 1.0 schema
+	Calculated []RTZScheduleElement `xml:"calculated>sheduleElement"`
+}
+
+type RTZScheduleElement struct {
+	WaypointId int     `xml:"waypointId,attr"`
+	Speed      float64 `xml:"speed,attr,omitempty"` // unit knots
+	Eta        string  `xml:"eta,attr,omitempty"`   // passage time in UTC, only present iff the departure is known
+}
+
+// Create a RTZ route from the path of a RouteResponse.
+// The schedule with the planned speed (unit knots) of each leg is omitted iff speed is not positive.
+func NewRTZRoute(name string, path Path, speed float64) RTZRoute {
+	route := RTZRoute{Version: "1.0", Info: RTZRouteInfo{RouteName: name}, Waypoints: make([]RTZWaypoint, 0, len(path.Waypoints))}
+	for i, wp := range path.Waypoints {
+		waypoint := RTZWaypoint{Id: i + 1, Name: rtzWaypointName(i, len(path.Waypoints)), Position: RTZPosition{Lat: wp.Lat, Lon: wp.Lon}}
+		if i > 0 {
+			waypoint.Leg = &RTZLeg{GeometryType: rtzOrthodrome}
+		}
+		route.Waypoints = append(route.Waypoints, waypoint)
+	}
+
+	if speed > 0 {
+		schedule := RTZSchedule{Id: 1, Name: "Planned speed", Calculated: make([]RTZScheduleElement, 0, len(route.Waypoints))}
+		for _, waypoint := range route.Waypoints {
+			schedule.Calculated = append(schedule.Calculated, RTZScheduleElement{WaypointId: waypoint.Id, Speed: speed})
+		}
+		route.Schedules = &RTZSchedules{Schedules: []RTZSchedule{schedule}}
+	}
+	return route
+}
+
+func rtzWaypointName(index, count int) string {
+	switch index {
+	case 0:
+		return "Origin"
+	case count - 1:
+		return "Destination"
+	default:
+		return fmt.Sprintf("WP%03d", index)
+	}
+}
+
+// Path returns the waypoints of the RTZ route. The length of the path is not part of the RTZ format and set to zero.
+func (route RTZRoute) Path() Path {
+	waypoints := make([]Point, 0, len(route.Waypoints))
+	for _, waypoint := range route.Waypoints {
+		waypoints = append(waypoints, Point{Lat: waypoint.Position.Lat, Lon: waypoint.Position.Lon})
+	}
+	return Path{Waypoints: waypoints}
+}
+
+// EncodeRTZ writes the path as RTZ route
+func EncodeRTZ(w io.Writer, res RouteResponse) error {
+	route := NewRTZRoute("Route", res.Path, res.Speed)
+	if res.Voyage != nil && route.Schedules != nil {
+		calculated := route.Schedules.Schedules[0].Calculated
+		for i, passage := range res.Voyage.Waypoints {
+			if passage.Passage != nil && i < len(calculated) {
+				calculated[i].Eta = passage.Passage.UTC().Format(time.RFC3339)
+			}
+		}
+	}
+	return encodeXML(w, route)
+}
+
+// DecodeRTZ reads a RTZ route
+func DecodeRTZ(r io.Reader) (RTZRoute, error) {
+	var route RTZRoute
+	err := xml.NewDecoder(r).Decode(&route)
+	if err != nil {
+		return route, err
+	}
+	if route.XMLName.Space != rtzNamespace {
+		return route, fmt.Errorf("unexpected RTZ namespace '%s'", route.XMLName.Space)
+	}
+	return route, nil
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRTZRoundTrip(t *testing.T) {
+	res := exampleResponse
+	res.Path.Waypoints = append(res.Path.Waypoints, Point{Lat: 31.2, Lon: 32.3})
+	res.Speed = 14.5
+
+	var buf bytes.Buffer
+	if err := EncodeRTZ(&buf, res); err != nil {
+		t.Fatal(err)
+	}
+	route, err := DecodeRTZ(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if route.Version != "1.0" {
+		t.Errorf("Unexpected version %s", route.Version)
+	}
+	path := route.Path()
+	if len(path.Waypoints) != len(res.Path.Waypoints) {
+		t.Fatalf("Expected %d waypoints, got %d", len(res.Path.Waypoints), len(path.Waypoints))
+	}
+	for i, wp := range path.Waypoints {
+		if wp != res.Path.Waypoints[i] {
+			t.Errorf("Waypoint %d: expected %v, got %v", i, res.Path.Waypoints[i], wp)
+		}
+	}
+	if route.Waypoints[0].Name != "Origin" || route.Waypoints[1].Name != "WP001" || route.Waypoints[2].Name != "Destination" {
+		t.Errorf("Unexpected waypoint names %v", route.Waypoints)
+	}
+	if route.Waypoints[0].Leg != nil || route.Waypoints[1].Leg == nil || route.Waypoints[1].Leg.GeometryType != rtzOrthodrome {
+		t.Errorf("Every waypoint except the first one should describe a great circle leg")
+	}
+	if route.Schedules == nil || len(route.Schedules.Schedules) != 1 {
+		t.Fatalf("Expected exactly one schedule")
+	}
+	if schedule := route.Schedules.Schedules[0]; len(schedule.Calculated) != 3 || schedule.Calculated[2].Speed != 14.5 {
+		t.Errorf("Unexpected schedules %+v", route.Schedules)
+	}
+}
+
+func TestRTZWithoutSpeed(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeRTZ(&buf, exampleResponse); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("schedule")) {
+		t.Errorf("RTZ route without planned speed should not contain a schedule:\n%s", buf.String())
+	}
+}
+
+func TestDecodeRTZWrongNamespace(t *testing.T) {
+	doc := `<route version="1.0" xmlns="http://www.topografix.com/GPX/1/1"><routeInfo routeName="x"/></route>`
+	if _, err := DecodeRTZ(bytes.NewBufferString(doc)); err == nil {
+		t.Errorf("Expected an error for a document with foreign namespace")
+	}
+}
+
+func TestRTZEta(t *testing.T) {
+	res := exampleResponse
+	res.Speed = 10
+	departure := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	res.Voyage = NewVoyage(res.Path, res.Speed, &departure)
+
+	var buf bytes.Buffer
+	if err := EncodeRTZ(&buf, res); err != nil {
+		t.Fatal(err)
+	}
+	route, err := DecodeRTZ(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calculated := route.Schedules.Schedules[0].Calculated
+	if calculated[0].Eta != "2024-03-01T11:00:00Z" {
+		t.Errorf("The ETA of the origin should be the departure in UTC, got %s", calculated[0].Eta)
+	}
+	if last := calculated[len(calculated)-1]; last.Eta != res.Voyage.Arrival.UTC().Format(time.RFC3339) {
+		t.Errorf("The ETA of the destination should be the arrival, got %s", last.Eta)
+	}
+}
@@ -1,62 +1,407 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"math"
+	"sort"
+	"strings"
 	"time"
 
 	sp "github.com/dmholtz/graffiti/algorithms/shortest_path"
 	heur "github.com/dmholtz/graffiti/examples/heuristics"
 	g "github.com/dmholtz/graffiti/graph"
+
+	"github.com/dmholtz/osm-ship-routing/pkg/coastline"
 )
 
 type IGeoPoint interface {
 	g.GeoPoint | g.PartGeoPoint | g.TwoLevelPartGeoPoint
 }
 
+// A ShipRouter processes requests until they are completed or their context is done.
+// If the context is done first, the error of the context is returned.
 type ShipRouter interface {
-	ProcessRequest(req RouteRequest, showSearchSpace bool) RouteResponse
+	ProcessRequest(ctx context.Context, req RouteRequest, showSearchSpace bool) (RouteResponse, error)
+	ProcessMatrixRequest(ctx context.Context, req MatrixRequest) (MatrixResponse, error)
+	ProcessIsochroneRequest(ctx context.Context, req IsochroneRequest) (IsochroneResponse, error)
+	ProcessAlternativesRequest(ctx context.Context, req AlternativesRequest) (AlternativesResponse, error)
 	String() string
 }
 
-type ShipRouter1[N IGeoPoint, E g.IHalfEdge] struct {
-	Graph  g.Graph[N, E]
-	Router sp.Router[int]
+// A RouterFactory creates a router operating on the given graph.
+// ShipRouter1 creates a router for each request, which operates on a view of the shared graph.
+type RouterFactory[N IGeoPoint, E g.IWeightedHalfEdge[int]] func(graph g.Graph[N, E]) sp.Router[int]
+
+type ShipRouter1[N IGeoPoint, E g.IWeightedHalfEdge[int]] struct {
+	Graph     g.Graph[N, E]
+	NewRouter RouterFactory[N, E]
+	Index     *NodeIndex  // spatial index for snapping points to nodes of Graph
+	Id        string      // id of the router, which labels its metrics and cached paths
+	Metrics   *Metrics    // optional, nil disables metrics
+	Cache     *RouteCache // optional, nil disables caching of shortest paths
+	// maximum distance in meters between a requested point and its snapped node, zero disables the limit.
+	// Points being farther away from the graph are considered to be on land.
+	MaxSnapDistance int
+	Coastlines      *coastline.Index // optional, nil disables path smoothing and distances to the coast
+	SafetyMargin    int              // minimum distance of each leg to the coast in meters, zero uses DefaultSafetyMargin
+	Zones           *ZoneIndex       // optional, nil disables penalty zones
+	Vessels         *VesselIndex     // optional, nil disables vessel profiles
+	Chokepoints     *ChokepointIndex // optional, nil disables avoiding chokepoints
+	Consumption     ConsumptionModel // optional, nil uses CubicLaw
+	// optional factory for routers on modified views of the graph, e.g. without the nodes inside avoid areas.
+	// Routers relying on preprocessing of the unrestricted graph such as arc flags must provide it, NewRouter is used iff nil.
+	NewRestrictedRouter RouterFactory[N, E]
 }
 
-func (sr ShipRouter1[N, E]) ProcessRequest(req RouteRequest, showSearchSpace bool) RouteResponse {
+// Create a new ShipRouter1 with a spatial index over the nodes of the graph.
+// Routers on the same graph should share the index, which is built from scratch iff index is nil.
+func NewShipRouter1[N IGeoPoint, E g.IWeightedHalfEdge[int]](graph g.Graph[N, E], newRouter RouterFactory[N, E], index *NodeIndex) ShipRouter1[N, E] {
+	if index == nil {
+		index = NewNodeIndex(graph)
+	}
+	return ShipRouter1[N, E]{Graph: graph, NewRouter: newRouter, Index: index}
+}
 
-	source := findClosestNode(sr.Graph, req.Origin)
-	target := findClosestNode(sr.Graph, req.Destination)
+// ProcessRequest computes a route from the origin via all via points to the destination.
+// A *RequestError is returned if the request is invalid, a point cannot be snapped to the graph or a point is not reachable.
+// If requested, the path of each leg is smoothed, such that via points remain waypoints of the path.
+// The search is aborted as soon as the context is done.
+func (sr ShipRouter1[N, E]) ProcessRequest(ctx context.Context, req RouteRequest, showSearchSpace bool) (RouteResponse, error) {
+	if err := req.Validate(); err != nil {
+		return RouteResponse{}, err
+	}
+	if req.Smooth && sr.Coastlines == nil {
+		return RouteResponse{}, NewRequestError(ErrorCodeInvalidValue, "smooth", "path smoothing is not available, since no coastlines are configured")
+	}
+	if req.CoastDistance && sr.Coastlines == nil {
+		return RouteResponse{}, NewRequestError(ErrorCodeInvalidValue, "coast_distance", "distances to the coast are not available, since no coastlines are configured")
+	}
+	view, err := sr.newRequestView(req)
+	if err != nil {
+		return RouteResponse{}, err
+	}
+	cache := sr.Cache
+	if !view.cacheable || showSearchSpace {
+		// the search space is not cached and cached paths are not applicable to graphs modified by the request
+		cache = nil
+	}
+	router := view.newRouter(newCancellableGraph[N, E](ctx, view.graph))
+
+	// snap origin, via points and destination to the graph
+	points := make([]Point, 0, len(req.Via)+2)
+	points = append(points, req.Origin)
+	points = append(points, req.Via...)
+	points = append(points, req.Destination)
+	stops := make([]g.NodeId, len(points))
+	snappedPoints := make([]SnappedPoint, len(points))
+	for i, p := range points {
+		nodeId, snapDistance, err := sr.snap(p, stopField(i, len(points)))
+		if err != nil {
+			return RouteResponse{}, err
+		}
+		if view.vessel != nil && view.vessel.blockedNodes[nodeId] {
+			return RouteResponse{}, NewRequestError(ErrorCodeRestrictedPoint, stopField(i, len(points)), "the closest node of the graph must not be used by vessel %s", req.Vessel)
+		}
+		if view.restricted != nil && view.restricted.Blocked(nodeId) {
+			return RouteResponse{}, NewRequestError(ErrorCodePointInAvoidArea, stopField(i, len(points)), "the closest node of the graph is inside an avoid area or an avoided chokepoint")
+		}
+		stops[i] = nodeId
+		snappedPoints[i] = SnappedPoint{Requested: p, Snapped: getPoint(sr.Graph.GetNode(nodeId)), Distance: snapDistance}
+	}
 
 	startTime := time.Now()
-	res := sr.Router.Route(source, target, showSearchSpace)
+	length, cost := 0, 0
+	legPaths := make([][]g.NodeId, 0, len(stops)-1)
+	legs := make([]Leg, 0, len(stops)-1)
+	var searchSpaceIds []g.NodeId
+	settledNodes := 0
+	cacheHit := true
+	for i := 1; i < len(stops); i++ {
+		legStartTime := time.Now()
+		res, legCacheHit, err := sr.route(ctx, router, cache, view.cacheKey, stops[i-1], stops[i], showSearchSpace)
+		if err != nil {
+			return RouteResponse{}, err
+		}
+		if res.Length < 0 {
+			sr.Metrics.ObserveUnreachable(sr.Id)
+			return RouteResponse{}, NewRequestError(ErrorCodeUnreachable, stopField(i, len(stops)), "no route from %s to %s exists", stopField(i-1, len(stops)), stopField(i, len(stops)))
+		}
+		legLength := res.Length
+		if view.weighted {
+			// the length of the path differs from its cost in the weighted graph
+			legLength = sr.pathLength(res.Path)
+		}
+		leg := Leg{Exists: true, Length: legLength, Time: time.Since(legStartTime).Milliseconds(), CacheHit: legCacheHit}
+		legs = append(legs, leg)
+		settledNodes += res.PqPops
+		cacheHit = cacheHit && legCacheHit
+
+		if showSearchSpace {
+			searchSpaceIds = append(searchSpaceIds, res.SearchSpace...)
+		}
+		length += legLength
+		cost += res.Length
+		legPaths = append(legPaths, res.Path)
+	}
+
+	waypoints := make([]Point, 0)
+	legWaypointLists := make([][]Point, 0, len(legPaths))
+	var zoneDistances []ZoneDistance
+	if sr.Zones != nil {
+		zoneDistances = make([]ZoneDistance, 0)
+	}
+	var smoothing *Smoothing
+	if req.Smooth {
+		smoothing = &Smoothing{RawLength: length}
+		length = 0
+	}
+	for i, legPath := range legPaths {
+		legWaypoints := make([]Point, 0, len(legPath))
+		for _, nodeId := range legPath {
+			legWaypoints = append(legWaypoints, getPoint(sr.Graph.GetNode(nodeId)))
+		}
+		if sr.Zones != nil {
+			zoneDistances = mergeZoneDistances(zoneDistances, sr.Zones.distances(legWaypoints, req.Zones))
+		}
+		if req.Smooth {
+			smoothed := SmoothWaypoints(legWaypoints, sr.Coastlines)
+			smoothing.RemovedWaypoints += len(legWaypoints) - len(smoothed)
+			legWaypoints = smoothed
+			legs[i].Length = polylineLength(smoothed)
+			length += legs[i].Length
+		}
+		legWaypointLists = append(legWaypointLists, legWaypoints)
+		// consecutive legs share their first and last node, respectively
+		if len(waypoints) > 0 && len(legWaypoints) > 0 {
+			legWaypoints = legWaypoints[1:]
+		}
+		waypoints = append(waypoints, legWaypoints...)
+	}
+	if req.Smooth {
+		smoothing.SmoothedLength = length
+	}
 	elapsed := time.Since(startTime).Milliseconds()
 
-	var path Path
-	if res.Length > 0 {
-		waypoints := make([]Point, 0)
-		for _, nodeId := range res.Path {
-			node := sr.Graph.GetNode(nodeId)
-			waypoints = append(waypoints, getPoint(node))
+	if !cacheHit {
+		sr.Metrics.ObserveSearchSpace(sr.Id, settledNodes)
+	}
+
+	path := Path{Length: length, Waypoints: waypoints}
+	if req.IncludeRequestedPoints {
+		origin, destination := snappedPoints[0], snappedPoints[len(snappedPoints)-1]
+		path = path.Extend(origin, destination)
+		legs[0].Length += origin.Distance
+		legs[len(legs)-1].Length += destination.Distance
+		if origin.Requested != origin.Snapped {
+			legWaypointLists[0] = append([]Point{origin.Requested}, legWaypointLists[0]...)
+		}
+		if destination.Requested != destination.Snapped {
+			last := len(legWaypointLists) - 1
+			legWaypointLists[last] = append(legWaypointLists[last][:len(legWaypointLists[last]):len(legWaypointLists[last])], destination.Requested)
 		}
-		path = Path{Length: res.Length, Waypoints: waypoints}
 	}
 
 	var searchSpace []Point
 	if showSearchSpace {
 		searchSpace = make([]Point, 0)
-		for _, nodeId := range res.SearchSpace {
+		for _, nodeId := range searchSpaceIds {
 			node := sr.Graph.GetNode(nodeId)
 			searchSpace = append(searchSpace, getPoint(node))
 		}
 	}
 
-	return RouteResponse{Exists: res.Length > 0, Time: elapsed, Path: path, SearchSpace: searchSpace}
+	res := RouteResponse{Exists: true, Time: elapsed, Path: path, Legs: legs, SearchSpace: searchSpace, Speed: req.Speed, CacheHit: cacheHit, Smoothing: smoothing,
+		Origin: snappedPoints[0], Destination: snappedPoints[len(snappedPoints)-1], Via: snappedPoints[1 : len(snappedPoints)-1], Zones: zoneDistances}
+	if view.weighted {
+		res.Cost = cost
+	}
+	if req.Speed > 0 {
+		res.Voyage = NewVoyage(path, req.Speed, req.Departure)
+	}
+	if vessel, speed, ok := sr.consumingVessel(req); ok {
+		res.Emissions = sr.emissions(legWaypointLists, vessel, speed, req.Zones)
+	}
+	if req.CoastDistance {
+		if res.Clearance, err = sr.clearance(ctx, path.Waypoints, sr.safetyMargin(req)); err != nil {
+			return RouteResponse{}, err
+		}
+	}
+	return res, nil
+}
+
+// A view of the graph for a single request and a factory for routers operating on the view
+type requestView[N IGeoPoint, E g.IWeightedHalfEdge[int]] struct {
+	graph      g.Graph[N, E]
+	newRouter  RouterFactory[N, E]
+	modified   bool                   // true iff the view differs from the shared graph
+	restricted *restrictedGraph[N, E] // nil unless the request contains avoid areas, avoided chokepoints or a restricted vessel
+	vessel     *restrictionSet        // nil unless the vessel of the request is restricted
+	weighted   bool                   // true iff the request applies penalty zones
+	cacheable  bool                   // false iff paths on the view must not be cached
+	cacheKey   string                 // key of the cached paths on the view
+}
+
+// Create the view of the graph for the request.
+// Avoid areas, chokepoints, vessel profiles and penalty zones modify the graph for this request only, such that the routers relying on preprocessing of the shared graph are replaced.
+// Since the restrictions of vessels and chokepoints are shared by all requests, paths on views, which are only modified by them, are cached per vessel and set of chokepoints.
+func (sr ShipRouter1[N, E]) newRequestView(req RouteRequest) (requestView[N, E], error) {
+	view := requestView[N, E]{graph: sr.Graph, newRouter: sr.NewRouter, cacheable: true, cacheKey: sr.Id}
+	sets := make([]*restrictionSet, 0)
+
+	if req.Vessel != "" {
+		if sr.Vessels == nil {
+			return view, NewRequestError(ErrorCodeInvalidValue, "vessel", "vessel profiles are not available, since no vessels are configured")
+		}
+		vr, ok := sr.Vessels.restrictions[req.Vessel]
+		if !ok {
+			return view, NewRequestError(ErrorCodeInvalidValue, "vessel", "unknown vessel %s", req.Vessel)
+		}
+		if vr.restricted {
+			view.vessel = vr
+			view.cacheKey += "|vessel=" + req.Vessel
+			sets = append(sets, vr)
+		}
+	}
+
+	if len(req.Avoid) > 0 {
+		if sr.Chokepoints == nil {
+			return view, NewRequestError(ErrorCodeInvalidValue, "avoid", "chokepoints are not available, since no chokepoints are configured")
+		}
+		avoided := make([]string, 0, len(req.Avoid))
+		for i, id := range req.Avoid {
+			cr, ok := sr.Chokepoints.restrictions[id]
+			if !ok {
+				return view, NewRequestError(ErrorCodeInvalidValue, fmt.Sprintf("avoid[%d]", i), "unknown chokepoint %s", id)
+			}
+			if !cr.restricted {
+				// avoiding the chokepoint would silently have no effect, e.g. for canals of graphs built without them
+				return view, NewRequestError(ErrorCodeInvalidValue, fmt.Sprintf("avoid[%d]", i), "chokepoint %s is not contained in the graph of router %s", id, sr.Id)
+			}
+			avoided = append(avoided, id)
+			sets = append(sets, cr)
+		}
+		sort.Strings(avoided)
+		view.cacheKey += "|avoid=" + strings.Join(avoided, ",")
+	}
+
+	areas, err := newAvoidAreas(req.AvoidAreas)
+	if err != nil {
+		return view, err
+	}
+	if len(areas) > 0 || len(sets) > 0 {
+		restricted := newRestrictedGraph[N, E](view.graph, areas, sets)
+		view.graph, view.restricted, view.modified = restricted, &restricted, true
+	}
+	if len(areas) > 0 {
+		view.cacheable = false
+	}
+
+	var fuel *fuelWeights
+	if req.Optimize == OptimizeFuel {
+		vessel, speed, ok := sr.consumingVessel(req)
+		if !ok {
+			return view, NewRequestError(ErrorCodeInvalidValue, "optimize", "minimizing fuel requires a vessel, whose consumption is known")
+		}
+		// without speed limits, the consumption is proportional to the distance
+		if sr.Zones != nil && len(req.Zones) > 0 {
+			fuel = newFuelWeights(sr.consumption(), vessel, speed, sr.Zones.speedLimits(req.Zones))
+		}
+	}
+
+	if len(req.Zones) > 0 {
+		if sr.Zones == nil {
+			return view, NewRequestError(ErrorCodeInvalidValue, "zones", "penalty zones are not available, since no zones are configured")
+		}
+		for i, set := range req.Zones {
+			if !sr.Zones.HasSet(set) {
+				return view, NewRequestError(ErrorCodeInvalidValue, fmt.Sprintf("zones[%d]", i), "unknown zone set %s", set)
+			}
+		}
+		view.graph, view.weighted, view.modified = newWeightedGraph[N, E](view.graph, sr.Zones, req.Zones, fuel), true, true
+		view.cacheable = false
+	}
+
+	if view.modified && sr.NewRestrictedRouter != nil {
+		view.newRouter = sr.NewRestrictedRouter
+	}
+	return view, nil
+}
+
+// Length of the path along the edges of the graph, unit meters.
+// Consecutive nodes of the path, which are not adjacent, e.g. in any-angle paths, are connected by a great circle arc.
+func (sr ShipRouter1[N, E]) pathLength(path []g.NodeId) int {
+	length := 0
+	for i := 1; i < len(path); i++ {
+		weight := -1
+		for _, edge := range sr.Graph.GetHalfEdgesFrom(path[i-1]) {
+			if edge.To() == path[i] {
+				weight = edge.Weight()
+				break
+			}
+		}
+		if weight < 0 {
+			weight = distance(getPoint(sr.Graph.GetNode(path[i-1])), getPoint(sr.Graph.GetNode(path[i])))
+		}
+		length += weight
+	}
+	return length
+}
+
+// Compute the shortest path from source to target, unless the cache contains the path.
+// A nil cache is bypassed, e.g. if the search space is requested, since the search space is not cached.
+// Paths are cached under the key, which distinguishes the router and the view of the graph.
+// The second return value is true iff the path has been taken from the cache.
+func (sr ShipRouter1[N, E]) route(ctx context.Context, router sp.Router[int], cache *RouteCache, key string, source, target g.NodeId, recordSearchSpace bool) (sp.ShortestPathResult[int], bool, error) {
+	if cache != nil {
+		if length, path, ok := cache.Get(key, source, target); ok {
+			sr.Metrics.ObserveCacheLookup(sr.Id, true)
+			return sp.ShortestPathResult[int]{Length: length, Path: path}, true, nil
+		}
+		sr.Metrics.ObserveCacheLookup(sr.Id, false)
+	}
+
+	res := router.Route(source, target, recordSearchSpace)
+	if err := ctx.Err(); err != nil {
+		// the search has been aborted, so the result is incomplete
+		return res, false, err
+	}
+	if cache != nil {
+		cache.Put(key, source, target, res.Length, res.Path)
+	}
+	return res, false, nil
 }
 
 func (sr ShipRouter1[N, E]) String() string {
-	return sr.Router.(fmt.Stringer).String()
+	return sr.NewRouter(sr.Graph).(fmt.Stringer).String()
+}
+
+// Snap the point to the closest node of the graph.
+// Returns the node and its distance to the point in meters, which is recorded by the metrics.
+// A *RequestError naming the field is returned iff the graph has no nodes or the distance exceeds the maximum snapping distance.
+func (sr ShipRouter1[N, E]) snap(p Point, field string) (g.NodeId, int, error) {
+	nodeId, ok := sr.closestNode(p)
+	if !ok {
+		return -1, 0, NewRequestError(ErrorCodeInternal, field, "the graph of router %s has no nodes", sr.Id)
+	}
+	snapDistance := distance(p, getPoint(sr.Graph.GetNode(nodeId)))
+	sr.Metrics.ObserveSnapDistance(sr.Id, snapDistance)
+	if sr.MaxSnapDistance > 0 && snapDistance > sr.MaxSnapDistance {
+		return -1, 0, NewRequestError(ErrorCodePointOnLand, field, "the closest node of the graph is %d m away, which exceeds the maximum snapping distance of %d m", snapDistance, sr.MaxSnapDistance)
+	}
+	return nodeId, snapDistance, nil
+}
+
+// Snap the point to the closest node of the graph. ok is false iff the graph has no nodes.
+func (sr ShipRouter1[N, E]) closestNode(p Point) (nodeId g.NodeId, ok bool) {
+	if sr.Index == nil {
+		if sr.Graph.NodeCount() == 0 {
+			return -1, false
+		}
+		// fall back to a linear scan
+		return findClosestNode(sr.Graph, p), true
+	}
+	return sr.Index.Nearest(p)
 }
 
 func getPoint[N IGeoPoint](n N) Point {
@@ -74,6 +419,11 @@ func getPoint[N IGeoPoint](n N) Point {
 	return point
 }
 
+// Great circle distance between two points, unit meters
+func distance(p, q Point) int {
+	return heur.Haversine(g.GeoPoint{Lat: p.Lat, Lon: p.Lon}, g.GeoPoint{Lat: q.Lat, Lon: q.Lon})
+}
+
 func findClosestNode[N IGeoPoint, E g.IHalfEdge](graph g.Graph[N, E], p Point) g.NodeId {
 	gp := g.GeoPoint{Lat: p.Lat, Lon: p.Lon}
 	minDist := math.MaxInt
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sp "github.com/dmholtz/graffiti/algorithms/shortest_path"
+	heur "github.com/dmholtz/graffiti/examples/heuristics"
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+type testGraph = g.AdjacencyArrayGraph[g.GeoPoint, g.WeightedHalfEdge[int]]
+
+// Grid graph with nodes at integer coordinates (lat=row, lon=col) and edges between horizontal and vertical neighbors.
+// The node id of (row, col) is row*cols+col.
+func gridGraph(rows, cols int) *testGraph {
+	alg := &g.AdjacencyListGraph[g.GeoPoint, g.WeightedHalfEdge[int]]{}
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			alg.AppendNode(g.GeoPoint{Lat: float64(row), Lon: float64(col)})
+		}
+	}
+	connect := func(from, to g.NodeId) {
+		distance := heur.Haversine(alg.GetNode(from), alg.GetNode(to))
+		alg.InsertHalfEdge(from, g.WeightedHalfEdge[int]{To_: to, Weight_: distance})
+		alg.InsertHalfEdge(to, g.WeightedHalfEdge[int]{To_: from, Weight_: distance})
+	}
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			if col+1 < cols {
+				connect(row*cols+col, row*cols+col+1)
+			}
+			if row+1 < rows {
+				connect(row*cols+col, (row+1)*cols+col)
+			}
+		}
+	}
+	return g.NewAdjacencyArrayFromGraph[g.GeoPoint, g.WeightedHalfEdge[int]](alg)
+}
+
+func newTestShipRouter(graph *testGraph) ShipRouter1[g.GeoPoint, g.WeightedHalfEdge[int]] {
+	newRouter := func(graph g.Graph[g.GeoPoint, g.WeightedHalfEdge[int]]) sp.Router[int] {
+		return sp.DijkstraRouter[g.GeoPoint, g.WeightedHalfEdge[int], int]{Graph: graph}
+	}
+	return NewShipRouter1[g.GeoPoint, g.WeightedHalfEdge[int]](graph, newRouter, nil)
+}
+
+// Process the request without deadline and fail the test on any error
+func mustRoute(t *testing.T, sr ShipRouter, req RouteRequest) RouteResponse {
+	res, err := sr.ProcessRequest(context.Background(), req, false)
+	if err != nil {
+		t.Fatalf("ProcessRequest(%v) failed: %v", req, err)
+	}
+	return res
+}
+
+func TestProcessRequestWithViaPoints(t *testing.T) {
+	graph := gridGraph(5, 5)
+	sr := newTestShipRouter(graph)
+
+	direct := mustRoute(t, sr, RouteRequest{Origin: Point{Lat: 0, Lon: 0}, Destination: Point{Lat: 0, Lon: 4}})
+	if !direct.Exists || len(direct.Legs) != 1 || direct.Legs[0].Length != direct.Path.Length {
+		t.Fatalf("Unexpected direct route: %+v", direct)
+	}
+
+	// detour via the opposite corner of the grid
+	req := RouteRequest{Origin: Point{Lat: 0, Lon: 0}, Via: []Point{{Lat: 4, Lon: 0}, {Lat: 4, Lon: 4}}, Destination: Point{Lat: 0, Lon: 4}}
+	res := mustRoute(t, sr, req)
+	if !res.Exists {
+		t.Fatalf("Route via points should exist")
+	}
+	if len(res.Legs) != 3 {
+		t.Fatalf("Expected 3 legs, got %d", len(res.Legs))
+	}
+	sum := 0
+	for _, leg := range res.Legs {
+		sum += leg.Length
+	}
+	if sum != res.Path.Length {
+		t.Errorf("Sum of leg lengths %d differs from path length %d", sum, res.Path.Length)
+	}
+	// 4+4+4 edges, shared stops are contained only once
+	if len(res.Path.Waypoints) != 13 {
+		t.Errorf("Expected 13 waypoints, got %d", len(res.Path.Waypoints))
+	}
+	for i, stop := range []Point{req.Origin, req.Via[0], req.Via[1], req.Destination} {
+		if wp := res.Path.Waypoints[4*i]; wp != stop {
+			t.Errorf("Waypoint %d is %v, expected stop %v", 4*i, wp, stop)
+		}
+	}
+	if res.Path.Length <= direct.Path.Length {
+		t.Errorf("Detour (%d) should be longer than the direct route (%d)", res.Path.Length, direct.Path.Length)
+	}
+}
+
+func TestProcessRequestCancelled(t *testing.T) {
+	graph := gridGraph(50, 50)
+	sr := newTestShipRouter(graph)
+	req := RouteRequest{Origin: Point{Lat: 0, Lon: 0}, Destination: Point{Lat: 49, Lon: 49}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := sr.ProcessRequest(ctx, req, false); err != context.Canceled {
+		t.Errorf("Cancelled request returned error %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if _, err := sr.ProcessMatrixRequest(ctx, MatrixRequest{Sources: []Point{req.Origin}, Targets: []Point{req.Destination}}); err != context.DeadlineExceeded {
+		t.Errorf("Matrix request after deadline returned error %v", err)
+	}
+	if _, err := sr.ProcessIsochroneRequest(ctx, IsochroneRequest{Origin: req.Origin, Bands: []int{1000000}}); err != context.DeadlineExceeded {
+		t.Errorf("Isochrone request after deadline returned error %v", err)
+	}
+
+	// the shared graph is not affected by cancelled requests
+	if res := mustRoute(t, sr, req); !res.Exists {
+		t.Errorf("Route should exist after cancelled requests")
+	}
+}
+
+func TestCancellableGraph(t *testing.T) {
+	graph := gridGraph(2, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	view := newCancellableGraph[g.GeoPoint, g.WeightedHalfEdge[int]](ctx, graph)
+
+	if got := len(view.GetHalfEdgesFrom(0)); got != 2 {
+		t.Errorf("Expected 2 edges before cancellation, got %d", got)
+	}
+	cancel()
+	if got := len(view.GetHalfEdgesFrom(0)); got != 0 {
+		t.Errorf("Expected no edges after cancellation, got %d", got)
+	}
+	if view.NodeCount() != graph.NodeCount() {
+		t.Errorf("Cancellation must not hide nodes")
+	}
+}
+
+func TestProcessRequestSnapping(t *testing.T) {
+	sr := newTestShipRouter(gridGraph(3, 3))
+	req := RouteRequest{Origin: Point{Lat: -0.2, Lon: 0}, Via: []Point{{Lat: 1, Lon: 1}}, Destination: Point{Lat: 2, Lon: 2.1}}
+
+	res := mustRoute(t, sr, req)
+	if res.Origin.Requested != req.Origin || res.Origin.Snapped != (Point{Lat: 0, Lon: 0}) || res.Origin.Distance != distance(req.Origin, res.Origin.Snapped) {
+		t.Errorf("Unexpected snapped origin %+v", res.Origin)
+	}
+	if res.Destination.Snapped != (Point{Lat: 2, Lon: 2}) || res.Destination.Distance <= 0 {
+		t.Errorf("Unexpected snapped destination %+v", res.Destination)
+	}
+	if len(res.Via) != 1 || res.Via[0].Distance != 0 {
+		t.Errorf("Unexpected snapped via points %+v", res.Via)
+	}
+
+	req.IncludeRequestedPoints = true
+	extended := mustRoute(t, sr, req)
+	if n := len(extended.Path.Waypoints); n != len(res.Path.Waypoints)+2 || extended.Path.Waypoints[0] != req.Origin || extended.Path.Waypoints[n-1] != req.Destination {
+		t.Errorf("Requested points are not contained in the waypoints %v", extended.Path.Waypoints)
+	}
+	if want := res.Path.Length + res.Origin.Distance + res.Destination.Distance; extended.Path.Length != want {
+		t.Errorf("Extended length is %d, expected %d", extended.Path.Length, want)
+	}
+	sum := 0
+	for _, leg := range extended.Legs {
+		sum += leg.Length
+	}
+	if sum != extended.Path.Length {
+		t.Errorf("Sum of leg lengths %d differs from path length %d", sum, extended.Path.Length)
+	}
+}
+
+func TestProcessRequestEmptyGraph(t *testing.T) {
+	req := RouteRequest{Origin: Point{Lat: 0, Lon: 0}, Destination: Point{Lat: 1, Lon: 1}}
+	linearScan := newTestShipRouter(gridGraph(0, 0))
+	linearScan.Index = nil
+	for _, sr := range []ShipRouter1[g.GeoPoint, g.WeightedHalfEdge[int]]{newTestShipRouter(gridGraph(0, 0)), linearScan} {
+		var reqErr *RequestError
+		if _, err := sr.ProcessRequest(context.Background(), req, false); !errors.As(err, &reqErr) || reqErr.Code != ErrorCodeInternal || reqErr.Field != "origin" {
+			t.Errorf("Expected internal error for the origin on an empty graph, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"github.com/dmholtz/osm-ship-routing/pkg/coastline"
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+)
+
+// Lengths of a path before and after smoothing, unit meters
+type Smoothing struct {
+	RawLength        int `json:"raw_length"`
+	SmoothedLength   int `json:"smoothed_length"`
+	RemovedWaypoints int `json:"removed_waypoints"`
+}
+
+// SmoothWaypoints removes staircase artifacts of grid graphs from a sequence of waypoints.
+// Runs of waypoints are greedily replaced by a direct great circle arc as long as the arc does not cross any coastline.
+// The first and the last waypoint are always retained, and so is every arc between consecutive waypoints.
+func SmoothWaypoints(waypoints []Point, coastlines *coastline.Index) []Point {
+	if len(waypoints) <= 2 {
+		return waypoints
+	}
+	points := make([]*geo.Point, len(waypoints))
+	for i, wp := range waypoints {
+		points[i] = geo.NewPoint(wp.Lat, wp.Lon)
+	}
+
+	smoothed := []Point{waypoints[0]}
+	for i := 0; i < len(waypoints)-1; {
+		// the arc to the next waypoint is part of the path and thus accepted without checking it
+		j := i + 1
+		for j+1 < len(waypoints) && !coastlines.Crosses(points[i], points[j+1]) {
+			j++
+		}
+		smoothed = append(smoothed, waypoints[j])
+		i = j
+	}
+	return smoothed
+}
+
+// Length of the polyline through the waypoints, unit meters
+func polylineLength(waypoints []Point) int {
+	length := 0
+	for i := 1; i < len(waypoints); i++ {
+		length += distance(waypoints[i-1], waypoints[i])
+	}
+	return length
+}
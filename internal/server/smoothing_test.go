@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dmholtz/osm-ship-routing/pkg/coastline"
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+)
+
+// Square island between the nodes of the grid graph, centered at (2.5, 2.5)
+func islandIndex() *coastline.Index {
+	island := geo.Polygon{geo.NewPoint(2.2, 2.2), geo.NewPoint(2.2, 2.8), geo.NewPoint(2.8, 2.8), geo.NewPoint(2.8, 2.2), geo.NewPoint(2.2, 2.2)}
+	return coastline.NewIndex([]geo.Polygon{island}, 1)
+}
+
+func TestSmoothWaypoints(t *testing.T) {
+	staircase := []Point{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 1}, {Lat: 1, Lon: 1}, {Lat: 1, Lon: 2}, {Lat: 2, Lon: 2}}
+
+	if smoothed := SmoothWaypoints(staircase, coastline.NewIndex(nil, 1)); len(smoothed) != 2 || smoothed[0] != staircase[0] || smoothed[1] != staircase[4] {
+		t.Errorf("Expected the staircase to be replaced by a single arc, got %v", smoothed)
+	}
+
+	// the island blocks the arc from the first to the last waypoint
+	around := []Point{{Lat: 2, Lon: 1}, {Lat: 2, Lon: 2}, {Lat: 2, Lon: 3}, {Lat: 2, Lon: 4}, {Lat: 3, Lon: 4}}
+	smoothed := SmoothWaypoints(around, islandIndex())
+	if len(smoothed) < 3 || smoothed[0] != around[0] || smoothed[len(smoothed)-1] != around[4] {
+		t.Errorf("Unexpected smoothed waypoints %v", smoothed)
+	}
+	for i := 1; i < len(smoothed); i++ {
+		if islandIndex().Crosses(geo.NewPoint(smoothed[i-1].Lat, smoothed[i-1].Lon), geo.NewPoint(smoothed[i].Lat, smoothed[i].Lon)) {
+			t.Errorf("Smoothed arc from %v to %v crosses the island", smoothed[i-1], smoothed[i])
+		}
+	}
+}
+
+func TestProcessRequestSmoothing(t *testing.T) {
+	sr := newTestShipRouter(gridGraph(6, 6))
+	sr.Coastlines = islandIndex()
+	req := RouteRequest{Origin: Point{Lat: 0, Lon: 0}, Via: []Point{{Lat: 1, Lon: 4}}, Destination: Point{Lat: 5, Lon: 5}}
+
+	raw := mustRoute(t, sr, req)
+	if raw.Smoothing != nil {
+		t.Errorf("Smoothing must only be reported if requested")
+	}
+
+	req.Smooth = true
+	res := mustRoute(t, sr, req)
+	if res.Smoothing == nil {
+		t.Fatalf("Smoothing is not reported")
+	}
+	if res.Smoothing.RawLength != raw.Path.Length || res.Smoothing.SmoothedLength != res.Path.Length {
+		t.Errorf("Unexpected smoothing %+v for raw length %d and smoothed length %d", res.Smoothing, raw.Path.Length, res.Path.Length)
+	}
+	if res.Path.Length >= raw.Path.Length {
+		t.Errorf("Smoothed length %d is not shorter than raw length %d", res.Path.Length, raw.Path.Length)
+	}
+	if removed := len(raw.Path.Waypoints) - len(res.Path.Waypoints); removed != res.Smoothing.RemovedWaypoints {
+		t.Errorf("Expected %d removed waypoints, got %d", removed, res.Smoothing.RemovedWaypoints)
+	}
+	found := false
+	for _, wp := range res.Path.Waypoints {
+		found = found || wp == req.Via[0]
+	}
+	if !found {
+		t.Errorf("Via point %v is not a waypoint of the smoothed path %v", req.Via[0], res.Path.Waypoints)
+	}
+	sum := 0
+	for _, leg := range res.Legs {
+		sum += leg.Length
+	}
+	if sum != res.Path.Length {
+		t.Errorf("Sum of leg lengths %d differs from path length %d", sum, res.Path.Length)
+	}
+}
+
+func TestProcessRequestSmoothingUnavailable(t *testing.T) {
+	sr := newTestShipRouter(gridGraph(3, 3))
+	req := RouteRequest{Origin: Point{Lat: 0, Lon: 0}, Destination: Point{Lat: 2, Lon: 2}, Smooth: true}
+
+	_, err := sr.ProcessRequest(context.Background(), req, false)
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.Code != ErrorCodeInvalidValue || reqErr.Field != "smooth" {
+		t.Errorf("Expected invalid value error for field smooth, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dmholtz/osm-ship-routing/pkg/coastline"
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+)
+
+// Maximum number of waypoints of a track to validate
+const MaxTrackWaypoints = 10000
+
+// Validate checks the number of waypoints and their coordinates
+func (req ValidationRequest) Validate() error {
+	if len(req.Waypoints) < 2 || len(req.Waypoints) > MaxTrackWaypoints {
+		return NewRequestError(ErrorCodeInvalidValue, "waypoints", "number of waypoints %d is not in [2, %d]", len(req.Waypoints), MaxTrackWaypoints)
+	}
+	for i, wp := range req.Waypoints {
+		if err := wp.Validate(fmt.Sprintf("waypoints[%d]", i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateTrack checks each great circle arc between consecutive waypoints of the track against the coastline polygons.
+// The ids of the polygons are their indices in the coastline file. The validation does not depend on any graph.
+func ValidateTrack(ctx context.Context, coastlines *coastline.Index, req ValidationRequest) (ValidationResponse, error) {
+	if err := req.Validate(); err != nil {
+		return ValidationResponse{}, err
+	}
+	if coastlines == nil {
+		return ValidationResponse{}, NewRequestError(ErrorCodeNotAvailable, "", "track validation is not available, since no coastlines are configured")
+	}
+	startTime := time.Now()
+
+	res := ValidationResponse{Valid: true, Length: polylineLength(req.Waypoints), Violations: make([]Violation, 0), Polygons: make([]int, 0)}
+	touched := make(map[int]bool)
+	for i := 1; i < len(req.Waypoints); i++ {
+		if err := ctx.Err(); err != nil {
+			return ValidationResponse{}, err
+		}
+		if violation, ok := validateLeg(coastlines, req.Waypoints[i-1], req.Waypoints[i]); ok {
+			violation.Leg = i - 1
+			res.Violations = append(res.Violations, violation)
+			for _, polygon := range violation.Polygons {
+				touched[polygon] = true
+			}
+		}
+	}
+	for polygon := range touched {
+		res.Polygons = append(res.Polygons, polygon)
+	}
+	sort.Ints(res.Polygons)
+	res.Valid = len(res.Violations) == 0
+	res.Time = time.Since(startTime).Milliseconds()
+	return res, nil
+}
+
+// Check whether the great circle arc from one waypoint to the next one touches land.
+// The second return value is false iff the arc neither starts on land nor crosses any coastline.
+func validateLeg(coastlines *coastline.Index, from, to Point) (Violation, bool) {
+	a, b := geo.NewPoint(from.Lat, from.Lon), geo.NewPoint(to.Lat, to.Lon)
+	violation := Violation{Polygons: make([]int, 0)}
+	onLand := false
+	if polygon, ok := coastlines.Contains(a); ok {
+		violation.Point, violation.Polygons, onLand = from, append(violation.Polygons, polygon), true
+	}
+	for _, crossing := range coastlines.Crossings(a, b) {
+		if !onLand {
+			violation.Point, onLand = Point{Lat: crossing.Point.Lat(), Lon: crossing.Point.Lon()}, true
+		}
+		if !containsInt(violation.Polygons, crossing.Polygon) {
+			violation.Polygons = append(violation.Polygons, crossing.Polygon)
+		}
+	}
+	return violation, onLand
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestValidateTrack(t *testing.T) {
+	coastlines := islandIndex()
+
+	// the second leg crosses the island from west to east, the last leg starts on the island
+	track := []Point{{Lat: 2, Lon: 0}, {Lat: 2.5, Lon: 1}, {Lat: 2.5, Lon: 4}, {Lat: 4, Lon: 4}, {Lat: 2.5, Lon: 2.5}, {Lat: 0, Lon: 4}}
+	res, err := ValidateTrack(context.Background(), coastlines, ValidationRequest{Waypoints: track})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid || len(res.Violations) != 3 || len(res.Polygons) != 1 || res.Polygons[0] != 0 {
+		t.Fatalf("Expected three violations of polygon 0, got %+v", res)
+	}
+	if res.Length != polylineLength(track) {
+		t.Errorf("Length is %d, expected %d", res.Length, polylineLength(track))
+	}
+	crossing := res.Violations[0]
+	if crossing.Leg != 1 || math.Abs(crossing.Point.Lon-2.2) > 1e-3 || math.Abs(crossing.Point.Lat-2.5) > 1e-3 {
+		t.Errorf("Expected the first crossing of leg 1 at the western shore, got %+v", crossing)
+	}
+	// the leg ending on the island crosses its shore, the next leg starts on the island
+	if entering := res.Violations[1]; entering.Leg != 3 || entering.Point == track[4] {
+		t.Errorf("Expected leg 3 to cross the shore, got %+v", entering)
+	}
+	if leaving := res.Violations[2]; leaving.Leg != 4 || leaving.Point != track[4] || len(leaving.Polygons) != 1 {
+		t.Errorf("Expected leg 4 to start on land, got %+v", leaving)
+	}
+
+	res, err = ValidateTrack(context.Background(), coastlines, ValidationRequest{Waypoints: track[:2]})
+	if err != nil || !res.Valid || len(res.Violations) != 0 || len(res.Polygons) != 0 {
+		t.Errorf("Expected a valid track, got %+v (%v)", res, err)
+	}
+}
+
+func TestValidationRequestErrors(t *testing.T) {
+	coastlines := islandIndex()
+	invalid := map[string]ValidationRequest{
+		"waypoints":    {Waypoints: []Point{{Lat: 0, Lon: 0}}},
+		"waypoints[1]": {Waypoints: []Point{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 181}}},
+	}
+	for field, req := range invalid {
+		var reqErr *RequestError
+		if _, err := ValidateTrack(context.Background(), coastlines, req); !errors.As(err, &reqErr) || reqErr.Field != field {
+			t.Errorf("Expected error for field %s, got %v", field, err)
+		}
+	}
+
+	coastlines = nil
+	var reqErr *RequestError
+	if _, err := ValidateTrack(context.Background(), coastlines, ValidationRequest{Waypoints: []Point{{Lat: 0, Lon: 0}, {Lat: 1, Lon: 1}}}); !errors.As(err, &reqErr) || reqErr.Code != ErrorCodeNotAvailable {
+		t.Errorf("Expected not available error without coastlines, got %v", err)
+	}
+}
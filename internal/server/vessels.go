@@ -0,0 +1,156 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+// Simplified boundaries of polar waters, unit degree.
+// The Antarctic boundary of the IMO Polar Code is 60°S, whereas the irregular Arctic boundary is approximated by the Arctic Circle.
+const (
+	ArcticLatitude    = 66.56
+	AntarcticLatitude = -60.0
+)
+
+// Dimensions of a vessel, unit meters. Zero values are ignored by the restrictions.
+type VesselProfile struct {
+	Name        string  `json:"name"`
+	Draft       float64 `json:"draft"`        // depth of the keel below the waterline
+	AirDraft    float64 `json:"air_draft"`    // height above the waterline, e.g. for passing bridges
+	Beam        float64 `json:"beam"`         // width of the hull, e.g. for passing locks
+	PolarWaters bool    `json:"polar_waters"` // whether the vessel may enter polar waters
+	// coefficients of the consumption model, the consumption is unknown iff the design speed or fuel is zero
+	DesignSpeed float64 `json:"design_speed,omitempty"` // unit knots
+	DesignFuel  float64 `json:"design_fuel,omitempty"`  // fuel consumption at the design speed, unit tonnes per day
+	CO2Factor   float64 `json:"co2_factor,omitempty"`   // tonnes of CO2 per tonne of fuel, defaults to DefaultCO2Factor iff zero
+}
+
+// Limits of a node or an edge, unit meters. Zero values impose no limit.
+type Limits struct {
+	Depth    float64 `json:"depth,omitempty"`     // minimum water depth, which must exceed the draft of a vessel
+	AirDraft float64 `json:"air_draft,omitempty"` // vertical clearance, which must exceed the air draft of a vessel
+	Beam     float64 `json:"beam,omitempty"`      // maximum beam of a vessel, e.g. of canal locks
+}
+
+// Restrictions of the nodes and edges of a graph, which are stored alongside the graph.
+// Edge restrictions apply to both directions of an edge.
+type Restrictions struct {
+	Nodes []NodeRestriction `json:"nodes"`
+	Edges []EdgeRestriction `json:"edges"`
+}
+
+type NodeRestriction struct {
+	Id g.NodeId `json:"id"`
+	Limits
+}
+
+type EdgeRestriction struct {
+	From g.NodeId `json:"from"`
+	To   g.NodeId `json:"to"`
+	Limits
+}
+
+// LoadRestrictions reads the restrictions of a graph from a JSON file
+func LoadRestrictions(filename string) (Restrictions, error) {
+	var restrictions Restrictions
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return restrictions, err
+	}
+	if err := json.Unmarshal(bytes, &restrictions); err != nil {
+		return restrictions, fmt.Errorf("invalid restrictions file %s: %w", filename, err)
+	}
+	return restrictions, nil
+}
+
+// Permits reports whether the vessel complies with the limits
+func (l Limits) Permits(vessel VesselProfile) bool {
+	if l.Depth > 0 && vessel.Draft >= l.Depth {
+		return false
+	}
+	if l.AirDraft > 0 && vessel.AirDraft >= l.AirDraft {
+		return false
+	}
+	if l.Beam > 0 && vessel.Beam > l.Beam {
+		return false
+	}
+	return true
+}
+
+// VesselIndex stores the nodes and edges of a graph, which must not be used by each vessel profile
+type VesselIndex struct {
+	profiles     map[string]VesselProfile
+	restrictions map[string]*restrictionSet
+}
+
+// Create a VesselIndex by checking the restrictions and the polar waters of the graph for each profile.
+// An error is returned if a restriction refers to a node or an edge, which is not contained in the graph.
+func NewVesselIndex[N IGeoPoint, E g.IWeightedHalfEdge[int]](graph g.Graph[N, E], profiles []VesselProfile, restrictions Restrictions) (*VesselIndex, error) {
+	for _, nr := range restrictions.Nodes {
+		if nr.Id < 0 || nr.Id >= graph.NodeCount() {
+			return nil, fmt.Errorf("restricted node %d is not contained in the graph", nr.Id)
+		}
+	}
+	for _, er := range restrictions.Edges {
+		if !hasEdge(graph, er.From, er.To) {
+			return nil, fmt.Errorf("restricted edge from %d to %d is not contained in the graph", er.From, er.To)
+		}
+	}
+
+	vi := &VesselIndex{profiles: make(map[string]VesselProfile), restrictions: make(map[string]*restrictionSet)}
+	for _, profile := range profiles {
+		vr := newRestrictionSet(graph.NodeCount())
+		if !profile.PolarWaters {
+			for id := 0; id < graph.NodeCount(); id++ {
+				if p := getPoint(graph.GetNode(id)); p.Lat >= ArcticLatitude || p.Lat <= AntarcticLatitude {
+					vr.blockNode(id)
+				}
+			}
+		}
+		for _, nr := range restrictions.Nodes {
+			if !nr.Permits(profile) {
+				vr.blockNode(nr.Id)
+			}
+		}
+		for _, er := range restrictions.Edges {
+			if !er.Permits(profile) {
+				vr.blockEdge(er.From, er.To)
+			}
+		}
+		vi.profiles[profile.Name] = profile
+		vi.restrictions[profile.Name] = vr
+	}
+	return vi, nil
+}
+
+// Profile returns the vessel profile with the given name
+func (vi *VesselIndex) Profile(name string) (VesselProfile, bool) {
+	profile, ok := vi.profiles[name]
+	return profile, ok
+}
+
+// Names returns the names of all vessel profiles in alphabetical order
+func (vi *VesselIndex) Names() []string {
+	names := make([]string, 0, len(vi.profiles))
+	for name := range vi.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func hasEdge[N any, E g.IHalfEdge](graph g.Graph[N, E], from, to g.NodeId) bool {
+	if from < 0 || from >= graph.NodeCount() {
+		return false
+	}
+	for _, edge := range graph.GetHalfEdgesFrom(from) {
+		if edge.To() == to {
+			return true
+		}
+	}
+	return false
+}
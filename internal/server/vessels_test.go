@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	g "github.com/dmholtz/graffiti/graph"
+)
+
+var testVessels = []VesselProfile{
+	{Name: "feeder", Draft: 9, AirDraft: 35, Beam: 25},
+	{Name: "vlcc", Draft: 22, AirDraft: 65, Beam: 60},
+	{Name: "icebreaker", Draft: 8, AirDraft: 30, Beam: 25, PolarWaters: true},
+}
+
+func TestLimitsPermits(t *testing.T) {
+	feeder, vlcc := testVessels[0], testVessels[1]
+	tests := []struct {
+		limits Limits
+		feeder bool
+		vlcc   bool
+	}{
+		{Limits{}, true, true},
+		{Limits{Depth: 15}, true, false},
+		{Limits{Depth: 9}, false, false}, // no under keel clearance
+		{Limits{AirDraft: 40}, true, false},
+		{Limits{Beam: 60}, true, true},
+		{Limits{Beam: 32}, true, false},
+	}
+	for _, test := range tests {
+		if test.limits.Permits(feeder) != test.feeder || test.limits.Permits(vlcc) != test.vlcc {
+			t.Errorf("Unexpected permits for limits %+v", test.limits)
+		}
+	}
+}
+
+func TestLoadRestrictions(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "restrictions.json")
+	content := `{"nodes": [{"id": 12, "depth": 15}], "edges": [{"from": 11, "to": 12, "beam": 32, "air_draft": 57}]}`
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	restrictions, err := LoadRestrictions(filename)
+	if err != nil {
+		t.Fatalf("LoadRestrictions failed: %v", err)
+	}
+	if len(restrictions.Nodes) != 1 || restrictions.Nodes[0].Id != 12 || restrictions.Nodes[0].Depth != 15 {
+		t.Errorf("Unexpected node restrictions %+v", restrictions.Nodes)
+	}
+	if len(restrictions.Edges) != 1 || restrictions.Edges[0].From != 11 || restrictions.Edges[0].Beam != 32 || restrictions.Edges[0].AirDraft != 57 {
+		t.Errorf("Unexpected edge restrictions %+v", restrictions.Edges)
+	}
+}
+
+func TestNewVesselIndex(t *testing.T) {
+	graph := gridGraph(3, 3)
+	restrictions := Restrictions{Nodes: []NodeRestriction{{Id: 4, Limits: Limits{Depth: 15}}}, Edges: []EdgeRestriction{{From: 0, To: 1, Limits: Limits{Beam: 32}}}}
+	vessels, err := NewVesselIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph, testVessels, restrictions)
+	if err != nil {
+		t.Fatalf("NewVesselIndex failed: %v", err)
+	}
+	if names := vessels.Names(); len(names) != 3 || names[0] != "feeder" || names[2] != "vlcc" {
+		t.Errorf("Unexpected names %v", names)
+	}
+	if vr := vessels.restrictions["feeder"]; vr.restricted {
+		t.Errorf("The feeder should not be restricted")
+	}
+	vr := vessels.restrictions["vlcc"]
+	if !vr.restricted || !vr.blockedNodes[4] || vr.blockedNodes[3] {
+		t.Errorf("Only node 4 should be blocked for the vlcc")
+	}
+	if _, ok := vr.blockedEdges[[2]g.NodeId{1, 0}]; !ok || len(vr.blockedEdges) != 2 {
+		t.Errorf("The edge between node 0 and 1 should be blocked in both directions, got %v", vr.blockedEdges)
+	}
+
+	invalid := []Restrictions{
+		{Nodes: []NodeRestriction{{Id: 9}}},
+		{Edges: []EdgeRestriction{{From: 0, To: 4}}},
+	}
+	for _, restrictions := range invalid {
+		if _, err := NewVesselIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph, testVessels, restrictions); err == nil {
+			t.Errorf("Restrictions %+v should be rejected", restrictions)
+		}
+	}
+}
+
+func TestNewVesselIndexPolarWaters(t *testing.T) {
+	alg := &g.AdjacencyListGraph[g.GeoPoint, g.WeightedHalfEdge[int]]{}
+	alg.AppendNode(g.GeoPoint{Lat: 60, Lon: 0})
+	alg.AppendNode(g.GeoPoint{Lat: 70, Lon: 0})
+	alg.AppendNode(g.GeoPoint{Lat: -65, Lon: 0})
+	vessels, err := NewVesselIndex[g.GeoPoint, g.WeightedHalfEdge[int]](alg, testVessels, Restrictions{})
+	if err != nil {
+		t.Fatalf("NewVesselIndex failed: %v", err)
+	}
+	if blocked := vessels.restrictions["feeder"].blockedNodes; blocked[0] || !blocked[1] || !blocked[2] {
+		t.Errorf("The feeder must not enter polar waters, got blocked nodes %v", blocked)
+	}
+	if vessels.restrictions["icebreaker"].restricted {
+		t.Errorf("The icebreaker may enter polar waters")
+	}
+}
+
+func TestProcessRequestVessel(t *testing.T) {
+	graph := gridGraph(5, 5)
+	sr := newTestShipRouter(graph)
+	sr.Id = "dijkstra"
+	sr.Cache = NewRouteCache(10, 0)
+	// shallow strait at the center of the grid
+	restrictions := Restrictions{Nodes: []NodeRestriction{{Id: 2*5 + 2, Limits: Limits{Depth: 15}}}}
+	vessels, err := NewVesselIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph, testVessels, restrictions)
+	if err != nil {
+		t.Fatalf("NewVesselIndex failed: %v", err)
+	}
+	sr.Vessels = vessels
+	req := RouteRequest{Origin: Point{Lat: 2, Lon: 0}, Destination: Point{Lat: 2, Lon: 4}}
+	direct := mustRoute(t, sr, req)
+
+	req.Vessel = "feeder"
+	feeder := mustRoute(t, sr, req)
+	if feeder.Path.Length != direct.Path.Length || !feeder.CacheHit {
+		t.Errorf("The unrestricted feeder should use the cached direct route, got length %d", feeder.Path.Length)
+	}
+
+	req.Vessel = "vlcc"
+	vlcc := mustRoute(t, sr, req)
+	if vlcc.Path.Length <= direct.Path.Length || vlcc.CacheHit {
+		t.Errorf("The vlcc should avoid the shallow strait, got length %d and direct length %d", vlcc.Path.Length, direct.Path.Length)
+	}
+	for _, wp := range vlcc.Path.Waypoints {
+		if wp.Lat == 2 && wp.Lon == 2 {
+			t.Errorf("Route of the vlcc passes the shallow strait")
+		}
+	}
+	if again := mustRoute(t, sr, req); !again.CacheHit || again.Path.Length != vlcc.Path.Length {
+		t.Errorf("Routes of the vlcc should be cached separately")
+	}
+
+	req.Origin = Point{Lat: 2, Lon: 2}
+	_, err = sr.ProcessRequest(context.Background(), req, false)
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.Code != ErrorCodeRestrictedPoint || reqErr.Field != "origin" {
+		t.Errorf("Expected restricted point error for the origin, got %v", err)
+	}
+
+	req.Vessel = "tanker"
+	if _, err := sr.ProcessRequest(context.Background(), req, false); !errors.As(err, &reqErr) || reqErr.Code != ErrorCodeInvalidValue || reqErr.Field != "vessel" {
+		t.Errorf("Expected invalid value error for field vessel, got %v", err)
+	}
+}
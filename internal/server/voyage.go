@@ -0,0 +1,50 @@
+package server
+
+import (
+	"math"
+	"time"
+)
+
+const metersPerNauticalMile = 1852
+
+// Voyage of a vessel along the waypoints of a path at a constant service speed
+type Voyage struct {
+	Speed     float64           `json:"speed"`               // service speed, unit knots
+	Duration  int64             `json:"duration"`            // unit seconds
+	Departure *time.Time        `json:"departure,omitempty"` // only present iff the departure has been requested
+	Arrival   *time.Time        `json:"eta,omitempty"`       // estimated time of arrival, only present iff the departure has been requested
+	Waypoints []WaypointPassage `json:"waypoints"`           // one entry per waypoint of the path
+}
+
+// Passage of a waypoint
+type WaypointPassage struct {
+	Distance   int        `json:"distance"`          // distance travelled since the first waypoint, unit meters
+	DistanceNm float64    `json:"distance_nm"`       // distance travelled since the first waypoint, unit nautical miles
+	Time       int64      `json:"time"`              // time elapsed since the departure, unit seconds
+	Passage    *time.Time `json:"passage,omitempty"` // only present iff the departure has been requested
+}
+
+// Create the voyage along the waypoints of the path at the given speed (unit knots), which must be positive.
+// Passage times are computed iff departure is not nil.
+func NewVoyage(path Path, speed float64, departure *time.Time) *Voyage {
+	voyage := &Voyage{Speed: speed, Departure: departure, Waypoints: make([]WaypointPassage, 0, len(path.Waypoints))}
+	metersPerSecond := speed * metersPerNauticalMile / 3600
+	distanceSum := 0
+	for i, wp := range path.Waypoints {
+		if i > 0 {
+			distanceSum += distance(path.Waypoints[i-1], wp)
+		}
+		passage := WaypointPassage{Distance: distanceSum, DistanceNm: float64(distanceSum) / metersPerNauticalMile}
+		passage.Time = int64(math.Round(float64(distanceSum) / metersPerSecond))
+		if departure != nil {
+			passageTime := departure.Add(time.Duration(passage.Time) * time.Second)
+			passage.Passage = &passageTime
+		}
+		voyage.Waypoints = append(voyage.Waypoints, passage)
+	}
+	if len(voyage.Waypoints) > 0 {
+		last := voyage.Waypoints[len(voyage.Waypoints)-1]
+		voyage.Duration, voyage.Arrival = last.Time, last.Passage
+	}
+	return voyage
+}
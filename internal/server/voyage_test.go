@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewVoyage(t *testing.T) {
+	// one degree of latitude is roughly 60 nautical miles, which take 6 hours at 10 knots
+	path := Path{Waypoints: []Point{{Lat: 0, Lon: 0}, {Lat: 1, Lon: 0}, {Lat: 2, Lon: 0}}}
+	departure := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	voyage := NewVoyage(path, 10, &departure)
+
+	if len(voyage.Waypoints) != 3 {
+		t.Fatalf("Expected 3 waypoint passages, got %d", len(voyage.Waypoints))
+	}
+	if first := voyage.Waypoints[0]; first.Distance != 0 || first.Time != 0 || !first.Passage.Equal(departure) {
+		t.Errorf("The first waypoint should be passed at the departure, got %+v", first)
+	}
+	last := voyage.Waypoints[2]
+	if last.Distance != 2*distance(path.Waypoints[0], path.Waypoints[1]) || math.Abs(last.DistanceNm-120) > 0.5 {
+		t.Errorf("Unexpected cumulative distance %d m (%v nm)", last.Distance, last.DistanceNm)
+	}
+	if voyage.Duration != last.Time || math.Abs(float64(voyage.Duration)-12*3600) > 60 {
+		t.Errorf("Unexpected duration %d s", voyage.Duration)
+	}
+	if voyage.Arrival == nil || !voyage.Arrival.Equal(departure.Add(time.Duration(voyage.Duration)*time.Second)) {
+		t.Errorf("Unexpected ETA %v", voyage.Arrival)
+	}
+
+	if voyage := NewVoyage(path, 10, nil); voyage.Arrival != nil || voyage.Waypoints[1].Passage != nil || voyage.Duration == 0 {
+		t.Errorf("Passage times require a departure, got %+v", voyage)
+	}
+}
+
+func TestProcessRequestVoyage(t *testing.T) {
+	sr := newTestShipRouter(gridGraph(3, 3))
+	req := RouteRequest{Origin: Point{Lat: 0, Lon: 0}, Destination: Point{Lat: 2, Lon: 2}}
+	if res := mustRoute(t, sr, req); res.Voyage != nil {
+		t.Errorf("The voyage requires a speed")
+	}
+
+	departure := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	req.Speed, req.Departure = 12, &departure
+	res := mustRoute(t, sr, req)
+	if res.Voyage == nil || len(res.Voyage.Waypoints) != len(res.Path.Waypoints) {
+		t.Fatalf("Expected one passage per waypoint, got %+v", res.Voyage)
+	}
+	if last := res.Voyage.Waypoints[len(res.Voyage.Waypoints)-1]; last.Distance != res.Path.Length || res.Voyage.Arrival == nil {
+		t.Errorf("The cumulative distance %d should equal the length %d of the path", last.Distance, res.Path.Length)
+	}
+
+	req.Speed = 0
+	var reqErr *RequestError
+	if _, err := sr.ProcessRequest(context.Background(), req, false); !errors.As(err, &reqErr) || reqErr.Field != "speed" {
+		t.Errorf("Expected invalid value error for field speed, got %v", err)
+	}
+}
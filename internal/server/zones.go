@@ -0,0 +1,336 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	g "github.com/dmholtz/graffiti/graph"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+)
+
+// A Zone increases the cost of all edges inside its polygons by a multiplier, e.g. Emission Control Areas or high-risk areas.
+// Zones are grouped into sets, which are applied per request.
+type Zone struct {
+	Id         string
+	Set        string
+	Multiplier float64 // at least 1, such that lower bounds of the unweighted graph remain valid
+	MaxSpeed   float64 // speed limit inside the zone, unit knots, zero imposes no limit
+	areas      []polygonArea
+}
+
+// Distance travelled inside a zone
+type ZoneDistance struct {
+	Id         string  `json:"id"`
+	Set        string  `json:"set"`
+	Multiplier float64 `json:"multiplier"`
+	MaxSpeed   float64 `json:"max_speed,omitempty"`
+	Applied    bool    `json:"applied"`  // true iff the zone set has been selected by the request
+	Distance   int     `json:"distance"` // unit meters
+}
+
+// LoadZones reads zones from a GeoJSON FeatureCollection.
+// Each feature is a polygon or multipolygon with the properties id, set and multiplier and the optional property max_speed.
+func LoadZones(filename string) ([]Zone, error) {
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	fc, err := geojson.UnmarshalFeatureCollection(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid zone file %s: %w", filename, err)
+	}
+	zones := make([]Zone, 0, len(fc.Features))
+	ids := make(map[string]bool)
+	for i, feature := range fc.Features {
+		zone, err := newZone(feature)
+		if err != nil {
+			return nil, fmt.Errorf("zone %d of file %s: %w", i, filename, err)
+		}
+		if ids[zone.Id] {
+			return nil, fmt.Errorf("duplicate zone id %s in file %s", zone.Id, filename)
+		}
+		ids[zone.Id] = true
+		zones = append(zones, zone)
+	}
+	return zones, nil
+}
+
+func newZone(feature *geojson.Feature) (Zone, error) {
+	zone := Zone{Id: feature.Properties.MustString("id", ""), Set: feature.Properties.MustString("set", ""), Multiplier: feature.Properties.MustFloat64("multiplier", 0),
+		MaxSpeed: feature.Properties.MustFloat64("max_speed", 0)}
+	if zone.Id == "" || zone.Set == "" {
+		return Zone{}, fmt.Errorf("id or set is missing")
+	}
+	if zone.Multiplier < 1 || math.IsInf(zone.Multiplier, 0) {
+		return Zone{}, fmt.Errorf("multiplier %v of zone %s must be at least 1", zone.Multiplier, zone.Id)
+	}
+	if math.IsNaN(zone.MaxSpeed) || zone.MaxSpeed < 0 {
+		return Zone{}, fmt.Errorf("speed limit %v of zone %s must not be negative", zone.MaxSpeed, zone.Id)
+	}
+
+	var polygons []orb.Polygon
+	switch geometry := feature.Geometry.(type) {
+	case orb.Polygon:
+		polygons = []orb.Polygon{geometry}
+	case orb.MultiPolygon:
+		polygons = geometry
+	default:
+		return Zone{}, fmt.Errorf("geometry of zone %s is not a polygon", zone.Id)
+	}
+	for _, polygon := range polygons {
+		area, err := newPolygonArea(polygon)
+		if err != nil {
+			return Zone{}, fmt.Errorf("zone %s: %w", zone.Id, err)
+		}
+		zone.areas = append(zone.areas, area)
+	}
+	return zone, nil
+}
+
+// Checks whether the point is inside any polygon of the zone
+func (zone Zone) contains(p *geo.Point) bool {
+	for _, area := range zone.areas {
+		if area.contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// ZoneIndex stores which edges of a graph are inside which zones.
+// An edge is inside a zone iff the midpoint of the edge is inside the zone.
+type ZoneIndex struct {
+	Zones   []Zone
+	offsets []int      // the zone edges of node i are stored in edges[offsets[i]:offsets[i+1]]
+	edges   []zoneEdge // one entry per pair of edge and zone
+	sets    map[string]bool
+}
+
+type zoneEdge struct {
+	to   g.NodeId
+	zone int
+}
+
+// Create a ZoneIndex by testing the midpoint of each edge of the graph against all zones
+func NewZoneIndex[N IGeoPoint, E g.IWeightedHalfEdge[int]](graph g.Graph[N, E], zones []Zone) *ZoneIndex {
+	zi := &ZoneIndex{Zones: zones, offsets: make([]int, graph.NodeCount()+1), edges: make([]zoneEdge, 0), sets: make(map[string]bool)}
+	for _, zone := range zones {
+		zi.sets[zone.Set] = true
+	}
+	for nodeId := 0; nodeId < graph.NodeCount(); nodeId++ {
+		from := getPoint(graph.GetNode(nodeId))
+		for _, edge := range graph.GetHalfEdgesFrom(nodeId) {
+			to := getPoint(graph.GetNode(edge.To()))
+			midpoint := geo.NewPoint(from.Lat, from.Lon).Midpoint(geo.NewPoint(to.Lat, to.Lon))
+			for zoneId, zone := range zones {
+				if zone.contains(midpoint) {
+					zi.edges = append(zi.edges, zoneEdge{to: edge.To(), zone: zoneId})
+				}
+			}
+		}
+		zi.offsets[nodeId+1] = len(zi.edges)
+	}
+	return zi
+}
+
+// HasSet reports whether any zone belongs to the set
+func (zi *ZoneIndex) HasSet(set string) bool {
+	return zi.sets[set]
+}
+
+// EdgeCount returns the number of pairs of edge and zone
+func (zi *ZoneIndex) EdgeCount() int {
+	return len(zi.edges)
+}
+
+// Returns the multiplier of each zone, which is 1 unless the zone belongs to one of the sets
+func (zi *ZoneIndex) multipliers(sets []string) []float64 {
+	selected := make(map[string]bool)
+	for _, set := range sets {
+		selected[set] = true
+	}
+	multipliers := make([]float64, len(zi.Zones))
+	for i, zone := range zi.Zones {
+		multipliers[i] = 1
+		if selected[zone.Set] {
+			multipliers[i] = zone.Multiplier
+		}
+	}
+	return multipliers
+}
+
+// Returns the speed limit of each zone, which is zero unless the zone belongs to one of the sets
+func (zi *ZoneIndex) speedLimits(sets []string) []float64 {
+	selected := make(map[string]bool)
+	for _, set := range sets {
+		selected[set] = true
+	}
+	limits := make([]float64, len(zi.Zones))
+	for i, zone := range zi.Zones {
+		if selected[zone.Set] {
+			limits[i] = zone.MaxSpeed
+		}
+	}
+	return limits
+}
+
+// Speed along the segment from one point to another, which is the lowest of the speed and the limits of the zones containing the midpoint of the segment
+func (zi *ZoneIndex) segmentSpeed(from, to Point, speed float64, limits []float64) float64 {
+	var midpoint *geo.Point
+	for zoneId, zone := range zi.Zones {
+		if limits[zoneId] <= 0 || limits[zoneId] >= speed {
+			continue
+		}
+		if midpoint == nil {
+			midpoint = geo.NewPoint(from.Lat, from.Lon).Midpoint(geo.NewPoint(to.Lat, to.Lon))
+		}
+		if zone.contains(midpoint) {
+			speed = limits[zoneId]
+		}
+	}
+	return speed
+}
+
+// Distances travelled inside each zone along the path, which is given by its waypoints.
+// A segment of the path is inside a zone iff its midpoint is inside the zone. Only zones with a positive distance are reported.
+func (zi *ZoneIndex) distances(waypoints []Point, sets []string) []ZoneDistance {
+	multipliers := zi.multipliers(sets)
+	distances := make([]int, len(zi.Zones))
+	for i := 1; i < len(waypoints); i++ {
+		from, to := waypoints[i-1], waypoints[i]
+		midpoint := geo.NewPoint(from.Lat, from.Lon).Midpoint(geo.NewPoint(to.Lat, to.Lon))
+		for zoneId, zone := range zi.Zones {
+			if zone.contains(midpoint) {
+				distances[zoneId] += distance(from, to)
+			}
+		}
+	}
+	zoneDistances := make([]ZoneDistance, 0)
+	for zoneId, zone := range zi.Zones {
+		if distances[zoneId] > 0 {
+			zoneDistances = append(zoneDistances, ZoneDistance{Id: zone.Id, Set: zone.Set, Multiplier: zone.Multiplier, MaxSpeed: zone.MaxSpeed, Applied: multipliers[zoneId] != 1, Distance: distances[zoneId]})
+		}
+	}
+	return zoneDistances
+}
+
+// Add the distances of b to the distances of the same zones in a
+func mergeZoneDistances(a, b []ZoneDistance) []ZoneDistance {
+	for _, zd := range b {
+		merged := false
+		for i := range a {
+			if a[i].Id == zd.Id {
+				a[i].Distance += zd.Distance
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			a = append(a, zd)
+		}
+	}
+	return a
+}
+
+// weightedGraph is a view of a graph, which multiplies the weight of each edge by the largest multiplier of the zones containing the edge.
+// When minimizing fuel, the weight is additionally multiplied by the relative fuel consumption per distance at the lowest speed limit of these zones.
+// The underlying graph, which may be shared by concurrent requests, is not modified.
+type weightedGraph[N IGeoPoint, E g.IWeightedHalfEdge[int]] struct {
+	g.Graph[N, E]
+	zones       *ZoneIndex
+	multipliers []float64
+	limits      []float64
+	fuel        *fuelWeights // nil unless fuel is minimized
+}
+
+// Relative fuel consumption per distance of a vessel, which scales the weights of the edges when fuel is minimized
+type fuelWeights struct {
+	perMeter func(speed float64) float64 // fuel consumption per distance at the speed (unit knots)
+	speed    float64                     // speed outside of speed limits, unit knots
+	min      float64                     // lowest consumption per distance at any speed limit, such that all factors are at least 1
+}
+
+// Create the fuel weights of the vessel at the speed, whose factors are relative to the lowest consumption at the speed or any lower speed limit
+func newFuelWeights(model ConsumptionModel, vessel VesselProfile, speed float64, limits []float64) *fuelWeights {
+	fw := &fuelWeights{speed: speed, perMeter: func(speed float64) float64 {
+		return model.Fuel(vessel, metersPerNauticalMile, speed) / metersPerNauticalMile
+	}}
+	fw.min = fw.perMeter(speed)
+	for _, limit := range limits {
+		if limit > 0 && limit < speed && fw.perMeter(limit) < fw.min {
+			fw.min = fw.perMeter(limit)
+		}
+	}
+	if !(fw.min > 0) {
+		return nil
+	}
+	return fw
+}
+
+func (fw *fuelWeights) factor(speed float64) float64 {
+	return fw.perMeter(speed) / fw.min
+}
+
+// Create a view of the graph, in which the zones of the given sets are applied.
+// The fuel weights are optional, nil does not minimize fuel.
+func newWeightedGraph[N IGeoPoint, E g.IWeightedHalfEdge[int]](graph g.Graph[N, E], zones *ZoneIndex, sets []string, fuel *fuelWeights) weightedGraph[N, E] {
+	return weightedGraph[N, E]{Graph: graph, zones: zones, multipliers: zones.multipliers(sets), limits: zones.speedLimits(sets), fuel: fuel}
+}
+
+func (wg weightedGraph[N, E]) GetHalfEdgesFrom(id g.NodeId) []E {
+	edges := wg.Graph.GetHalfEdgesFrom(id)
+	zoneEdges := wg.zones.edges[wg.zones.offsets[id]:wg.zones.offsets[id+1]]
+	if len(zoneEdges) == 0 && wg.fuel == nil {
+		return edges
+	}
+	// copy the edges to leave the underlying graph untouched
+	weighted := make([]E, len(edges))
+	for i, edge := range edges {
+		multiplier, speed := 1.0, 0.0
+		if wg.fuel != nil {
+			speed = wg.fuel.speed
+		}
+		for _, zoneEdge := range zoneEdges {
+			if zoneEdge.to != edge.To() {
+				continue
+			}
+			if wg.multipliers[zoneEdge.zone] > multiplier {
+				multiplier = wg.multipliers[zoneEdge.zone]
+			}
+			if limit := wg.limits[zoneEdge.zone]; limit > 0 && limit < speed {
+				speed = limit
+			}
+		}
+		if wg.fuel != nil {
+			multiplier *= wg.fuel.factor(speed)
+		}
+		weighted[i] = withWeight(edge, int(math.Round(float64(edge.Weight())*multiplier)))
+	}
+	return weighted
+}
+
+// Returns a copy of the edge with a different weight
+func withWeight[E g.IWeightedHalfEdge[int]](edge E, weight int) E {
+	var weighted any
+	switch e := any(edge).(type) {
+	case g.WeightedHalfEdge[int]:
+		e.Weight_ = weight
+		weighted = e
+	case g.FlaggedHalfEdge[int, uint64]:
+		e.Weight_ = weight
+		weighted = e
+	case g.LargeFlaggedHalfEdge[int]:
+		e.Weight_ = weight
+		weighted = e
+	case g.TwoLevelFlaggedHalfEdge[int, uint64, uint64]:
+		e.Weight_ = weight
+		weighted = e
+	default:
+		panic(fmt.Sprintf("Cannot change the weight of edge type %T.", edge))
+	}
+	return weighted.(E)
+}
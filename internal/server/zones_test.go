@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	g "github.com/dmholtz/graffiti/graph"
+	"github.com/paulmach/orb/geojson"
+)
+
+// Zone covering the edges along latitude 2 between longitude 1 and 3 of a grid graph
+func stripZone(t *testing.T, multiplier float64) Zone {
+	feature := geojson.NewFeature(rectangle(1.6, 1, 2.4, 3))
+	feature.Properties["id"] = "strip"
+	feature.Properties["set"] = "eca"
+	feature.Properties["multiplier"] = multiplier
+	zone, err := newZone(feature)
+	if err != nil {
+		t.Fatalf("Invalid zone: %v", err)
+	}
+	return zone
+}
+
+func TestLoadZones(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	polygon := `{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}`
+
+	zones, err := LoadZones(write("zones.geojson", `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": `+polygon+`, "properties": {"id": "baltic", "set": "eca", "multiplier": 1.3, "max_speed": 10}},
+		{"type": "Feature", "geometry": `+polygon+`, "properties": {"id": "gulf-of-aden", "set": "hra", "multiplier": 2}}]}`))
+	if err != nil {
+		t.Fatalf("LoadZones failed: %v", err)
+	}
+	if len(zones) != 2 || zones[0].Id != "baltic" || zones[0].Set != "eca" || zones[0].Multiplier != 1.3 || zones[0].MaxSpeed != 10 || zones[1].MaxSpeed != 0 || len(zones[1].areas) != 1 {
+		t.Errorf("Unexpected zones %+v", zones)
+	}
+
+	invalid := map[string]string{
+		"multiplier.geojson": `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": ` + polygon + `, "properties": {"id": "a", "set": "eca", "multiplier": 0.5}}]}`,
+		"max_speed.geojson":  `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": ` + polygon + `, "properties": {"id": "a", "set": "eca", "multiplier": 2, "max_speed": -1}}]}`,
+		"set.geojson":        `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": ` + polygon + `, "properties": {"id": "a", "multiplier": 2}}]}`,
+		"duplicate.geojson": `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": ` + polygon + `, "properties": {"id": "a", "set": "eca", "multiplier": 2}},
+			{"type": "Feature", "geometry": ` + polygon + `, "properties": {"id": "a", "set": "eca", "multiplier": 2}}]}`,
+		"point.geojson": `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "Point", "coordinates": [0, 0]}, "properties": {"id": "a", "set": "eca", "multiplier": 2}}]}`,
+	}
+	for name, content := range invalid {
+		if _, err := LoadZones(write(name, content)); err == nil {
+			t.Errorf("%s should be rejected", name)
+		}
+	}
+}
+
+func TestWeightedGraph(t *testing.T) {
+	graph := gridGraph(5, 5)
+	zones := NewZoneIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph, []Zone{stripZone(t, 2)})
+	// edges between (2,1), (2,2) and (2,3) in both directions
+	if zones.EdgeCount() != 4 {
+		t.Errorf("Expected 4 edges inside the zone, got %d", zones.EdgeCount())
+	}
+
+	view := newWeightedGraph[g.GeoPoint, g.WeightedHalfEdge[int]](graph, zones, []string{"eca"}, nil)
+	for i, edge := range view.GetHalfEdgesFrom(2*5 + 1) {
+		original := graph.GetHalfEdgesFrom(2*5 + 1)[i]
+		want := original.Weight()
+		if edge.To() == 2*5+2 {
+			want *= 2
+		}
+		if edge.Weight() != want {
+			t.Errorf("Edge to %d has weight %d, expected %d", edge.To(), edge.Weight(), want)
+		}
+	}
+	if unweighted := newWeightedGraph[g.GeoPoint, g.WeightedHalfEdge[int]](graph, zones, nil, nil); unweighted.GetHalfEdgesFrom(2*5 + 1)[0] != graph.GetHalfEdgesFrom(2*5 + 1)[0] {
+		t.Errorf("Zones of sets, which are not selected, must not change the weights")
+	}
+}
+
+func TestWithWeight(t *testing.T) {
+	flagged := g.LargeFlaggedHalfEdge[int]{To_: 3, Weight_: 10, MsbFlag: 1, LsbFlag: 2}
+	if got := withWeight(flagged, 20); got.Weight() != 20 || got.To() != 3 || got.MsbFlag != 1 || got.LsbFlag != 2 {
+		t.Errorf("Unexpected edge %v", got)
+	}
+	twoLevel := g.TwoLevelFlaggedHalfEdge[int, uint64, uint64]{To_: 3, Weight_: 10, L1Flag: 1, L2Flag: 2}
+	if got := withWeight(twoLevel, 20); got.Weight() != 20 || got.L1Flag != 1 || got.L2Flag != 2 {
+		t.Errorf("Unexpected edge %v", got)
+	}
+}
+
+func TestProcessRequestZones(t *testing.T) {
+	graph := gridGraph(5, 5)
+	req := RouteRequest{Origin: Point{Lat: 2, Lon: 0}, Destination: Point{Lat: 2, Lon: 4}}
+
+	// a small penalty is cheaper than the detour
+	sr := newTestShipRouter(graph)
+	sr.Zones = NewZoneIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph, []Zone{stripZone(t, 1.5)})
+	direct := mustRoute(t, sr, req)
+	if direct.Cost != 0 || len(direct.Zones) != 1 || direct.Zones[0].Applied || direct.Zones[0].Distance <= 0 {
+		t.Errorf("Expected the zone to be reported without being applied, got cost %d and zones %+v", direct.Cost, direct.Zones)
+	}
+
+	req.Zones = []string{"eca"}
+	penalized := mustRoute(t, sr, req)
+	if penalized.Path.Length != direct.Path.Length || penalized.Cost != direct.Path.Length+direct.Zones[0].Distance/2 {
+		t.Errorf("Unexpected length %d and cost %d", penalized.Path.Length, penalized.Cost)
+	}
+	if len(penalized.Zones) != 1 || !penalized.Zones[0].Applied || penalized.Zones[0].Distance != direct.Zones[0].Distance {
+		t.Errorf("Unexpected zones %+v", penalized.Zones)
+	}
+
+	// a large penalty causes a detour around the zone
+	sr.Zones = NewZoneIndex[g.GeoPoint, g.WeightedHalfEdge[int]](graph, []Zone{stripZone(t, 3)})
+	detour := mustRoute(t, sr, req)
+	if detour.Path.Length <= direct.Path.Length || len(detour.Zones) != 0 || detour.Cost != detour.Path.Length {
+		t.Errorf("Expected a detour around the zone, got length %d, cost %d and zones %+v", detour.Path.Length, detour.Cost, detour.Zones)
+	}
+	sum := 0
+	for _, leg := range detour.Legs {
+		sum += leg.Length
+	}
+	if sum != detour.Path.Length {
+		t.Errorf("Sum of leg lengths %d differs from path length %d", sum, detour.Path.Length)
+	}
+
+	req.Zones = []string{"hra"}
+	_, err := sr.ProcessRequest(context.Background(), req, false)
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.Code != ErrorCodeInvalidValue || reqErr.Field != "zones[0]" {
+		t.Errorf("Expected invalid value error for field zones[0], got %v", err)
+	}
+	sr.Zones = nil
+	if _, err := sr.ProcessRequest(context.Background(), req, false); !errors.As(err, &reqErr) || reqErr.Field != "zones" {
+		t.Errorf("Expected invalid value error for field zones, got %v", err)
+	}
+}
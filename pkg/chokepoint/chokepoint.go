@@ -0,0 +1,95 @@
+package chokepoint
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+
+	gr "github.com/dmholtz/osm-ship-routing/pkg/graph"
+)
+
+// A Chokepoint is a canal or strait, which can be avoided by route requests, e.g. to analyze the closure of the Suez Canal.
+// Canals are given by their center line, which is injected into the graph, since the coastline-based grid cannot represent them.
+// Straits, which are represented by the grid, are given by polygons covering their nodes.
+type Chokepoint struct {
+	Id    string
+	Name  string
+	Canal []gr.Node     // waypoints of the center line of a canal, nil for straits
+	Areas []orb.Polygon // polygons covering a strait, nil for canals
+	// limits of the vessels passing the chokepoint, unit meters. Zero values impose no limit.
+	Depth    float64 // water depth, which must exceed the draft of a vessel
+	AirDraft float64 // vertical clearance of bridges, which must exceed the air draft of a vessel
+	Beam     float64 // maximum beam of a vessel, e.g. of locks
+}
+
+// IsCanal reports whether the chokepoint is given by the center line of a canal
+func (c Chokepoint) IsCanal() bool {
+	return c.Canal != nil
+}
+
+// Load reads chokepoints from a GeoJSON FeatureCollection.
+// Each feature has the properties id and name and is a line string for canals or a polygon or multipolygon for straits.
+// The optional properties depth, air_draft and beam limit the vessels passing the chokepoint.
+func Load(filename string) ([]Chokepoint, error) {
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	fc, err := geojson.UnmarshalFeatureCollection(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chokepoint file %s: %w", filename, err)
+	}
+	chokepoints := make([]Chokepoint, 0, len(fc.Features))
+	ids := make(map[string]bool)
+	for i, feature := range fc.Features {
+		chokepoint, err := newChokepoint(feature)
+		if err != nil {
+			return nil, fmt.Errorf("chokepoint %d of file %s: %w", i, filename, err)
+		}
+		if ids[chokepoint.Id] {
+			return nil, fmt.Errorf("duplicate chokepoint id %s in file %s", chokepoint.Id, filename)
+		}
+		ids[chokepoint.Id] = true
+		chokepoints = append(chokepoints, chokepoint)
+	}
+	return chokepoints, nil
+}
+
+func newChokepoint(feature *geojson.Feature) (Chokepoint, error) {
+	chokepoint := Chokepoint{Id: feature.Properties.MustString("id", ""), Name: feature.Properties.MustString("name", ""),
+		Depth: feature.Properties.MustFloat64("depth", 0), AirDraft: feature.Properties.MustFloat64("air_draft", 0), Beam: feature.Properties.MustFloat64("beam", 0)}
+	if chokepoint.Id == "" {
+		return Chokepoint{}, fmt.Errorf("id is missing")
+	}
+	if chokepoint.Depth < 0 || chokepoint.AirDraft < 0 || chokepoint.Beam < 0 {
+		return Chokepoint{}, fmt.Errorf("limits of chokepoint %s must not be negative", chokepoint.Id)
+	}
+	switch geometry := feature.Geometry.(type) {
+	case orb.LineString:
+		if len(geometry) < 2 {
+			return Chokepoint{}, fmt.Errorf("canal %s requires at least 2 positions", chokepoint.Id)
+		}
+		chokepoint.Canal = make([]gr.Node, 0, len(geometry))
+		for _, position := range geometry {
+			chokepoint.Canal = append(chokepoint.Canal, gr.Node{Lon: position.Lon(), Lat: position.Lat()})
+		}
+	case orb.Polygon:
+		chokepoint.Areas = []orb.Polygon{geometry}
+	case orb.MultiPolygon:
+		chokepoint.Areas = geometry
+	default:
+		return Chokepoint{}, fmt.Errorf("geometry of chokepoint %s is neither a line string nor a polygon", chokepoint.Id)
+	}
+	return chokepoint, nil
+}
+
+// InjectCanals adds the center lines of all canals to the graph before it is converted to a static graph
+func InjectCanals(alg *gr.AdjacencyListGraph, chokepoints []Chokepoint) {
+	for _, chokepoint := range chokepoints {
+		if chokepoint.IsCanal() {
+			alg.AddCanal(chokepoint.Canal)
+		}
+	}
+}
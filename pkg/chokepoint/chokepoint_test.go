@@ -0,0 +1,95 @@
+package chokepoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	gr "github.com/dmholtz/osm-ship-routing/pkg/graph"
+)
+
+func TestLoadRegistry(t *testing.T) {
+	chokepoints, err := Load("../../chokepoints.geojson")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	ids := make(map[string]bool)
+	for _, chokepoint := range chokepoints {
+		ids[chokepoint.Id] = true
+		if !chokepoint.IsCanal() || len(chokepoint.Canal) < 2 {
+			t.Errorf("Chokepoint %s should be a canal", chokepoint.Id)
+		}
+	}
+	for _, id := range []string{"suez", "panama", "kiel", "bosporus"} {
+		if !ids[id] {
+			t.Errorf("Chokepoint %s is missing", id)
+		}
+	}
+	for _, chokepoint := range chokepoints {
+		if canal := chokepoint.Id; (canal == "suez" || canal == "panama" || canal == "kiel") && (chokepoint.Depth <= 0 || chokepoint.AirDraft <= 0 || chokepoint.Beam <= 0) {
+			t.Errorf("Limits of canal %s are missing, got %+v", canal, chokepoint)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+
+	chokepoints, err := Load(write("strait.geojson", `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[56, 26], [57, 26], [57, 27], [56, 26]]]}, "properties": {"id": "hormuz", "name": "Strait of Hormuz"}}]}`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(chokepoints) != 1 || chokepoints[0].IsCanal() || len(chokepoints[0].Areas) != 1 || chokepoints[0].Name != "Strait of Hormuz" {
+		t.Errorf("Unexpected chokepoints %+v", chokepoints)
+	}
+
+	invalid := map[string]string{
+		"id.geojson":     `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]}, "properties": {}}]}`,
+		"short.geojson":  `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0]]}, "properties": {"id": "a"}}]}`,
+		"limits.geojson": `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]}, "properties": {"id": "a", "depth": -1}}]}`,
+		"point.geojson":  `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "Point", "coordinates": [0, 0]}, "properties": {"id": "a"}}]}`,
+		"duplicate.geojson": `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]}, "properties": {"id": "a"}},
+			{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]}, "properties": {"id": "a"}}]}`,
+	}
+	for name, content := range invalid {
+		if _, err := Load(write(name, content)); err == nil {
+			t.Errorf("%s should be rejected", name)
+		}
+	}
+}
+
+func TestInjectCanals(t *testing.T) {
+	// two basins, which are only connected by the canal
+	alg := &gr.AdjacencyListGraph{}
+	alg.AddNode(gr.Node{Lon: 0, Lat: 0})
+	alg.AddNode(gr.Node{Lon: 0, Lat: 1})
+	alg.AddNode(gr.Node{Lon: 3, Lat: 0})
+	alg.AddNode(gr.Node{Lon: 3, Lat: 1})
+	alg.AddEdge(gr.Edge{From: 0, To: 1, Distance: 1})
+	alg.AddEdge(gr.Edge{From: 2, To: 3, Distance: 1})
+
+	canal := Chokepoint{Id: "canal", Canal: []gr.Node{{Lon: 1, Lat: 0.1}, {Lon: 2, Lat: 0.1}}}
+	strait := Chokepoint{Id: "strait"}
+	InjectCanals(alg, []Chokepoint{canal, strait})
+
+	if alg.NodeCount() != 6 || alg.EdgeCount() != 2+6 {
+		t.Fatalf("Expected 6 nodes and 8 edges, got %d nodes and %d edges", alg.NodeCount(), alg.EdgeCount())
+	}
+	if edges := alg.GetHalfEdgesFrom(4); len(edges) != 2 || edges[0].To != 0 || edges[1].To != 5 {
+		t.Errorf("First waypoint should be connected to node 0 and the second waypoint, got %v", edges)
+	}
+	if edges := alg.GetHalfEdgesFrom(5); len(edges) != 2 || edges[0].To != 4 || edges[1].To != 2 {
+		t.Errorf("Last waypoint should be connected to the first waypoint and node 2, got %v", edges)
+	}
+	if edges := alg.GetHalfEdgesFrom(4); edges[1].Distance < 100000 {
+		t.Errorf("Canal edge should have the great circle distance of its waypoints, got %d", edges[1].Distance)
+	}
+}
@@ -0,0 +1,282 @@
+package coastline
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"sort"
+
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+)
+
+// Length of one degree of a great circle, unit meters
+const metersPerDegree = 6371e3 * math.Pi / 180
+
+// Index is a spatial index over the edges of coastline polygons.
+// Each edge is assigned to all cells of a regular latitude / longitude grid, which the edge passes through.
+type Index struct {
+	polygons []geo.Polygon
+	cellSize float64 // unit degree
+	nLat     int
+	nLon     int
+	cells    [][]EdgeRef
+	bboxes   []geo.BoundingBox // bounding box of each polygon
+}
+
+// Crossing is a point, where a great circle arc crosses an edge of a coastline polygon
+type Crossing struct {
+	Point   *geo.Point
+	Polygon int // index of the polygon
+}
+
+// EdgeRef refers to the edge of a polygon from the point at Index to the next point
+type EdgeRef struct {
+	Polygon int
+	Index   int
+}
+
+// LoadPolyJson reads polygons from a .poly.json file as exported by the merger
+func LoadPolyJson(filename string) ([]geo.Polygon, error) {
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var polygons []geo.Polygon
+	if err := json.Unmarshal(bytes, &polygons); err != nil {
+		return nil, err
+	}
+	return polygons, nil
+}
+
+// Create an index over the edges of the polygons using cells of cellSize x cellSize degrees
+func NewIndex(polygons []geo.Polygon, cellSize float64) *Index {
+	nLat, nLon := int(math.Ceil(180/cellSize)), int(math.Ceil(360/cellSize))
+	idx := &Index{polygons: polygons, cellSize: cellSize, nLat: nLat, nLon: nLon, cells: make([][]EdgeRef, nLat*nLon), bboxes: make([]geo.BoundingBox, len(polygons))}
+	for polygonId := range polygons {
+		polygon := polygons[polygonId]
+		idx.bboxes[polygonId] = polygon.LatLonBoundingBox()
+		for i := range polygon {
+			from, to := idx.Edge(EdgeRef{Polygon: polygonId, Index: i})
+			if *from == *to {
+				// closed polygons repeat their first point
+				continue
+			}
+			for _, cell := range idx.cellsOnArc(from, to) {
+				idx.cells[cell] = append(idx.cells[cell], EdgeRef{Polygon: polygonId, Index: i})
+			}
+		}
+	}
+	return idx
+}
+
+// Polygons returns the indexed polygons
+func (idx *Index) Polygons() []geo.Polygon {
+	return idx.polygons
+}
+
+// Edge returns the end points of the referred edge
+func (idx *Index) Edge(ref EdgeRef) (*geo.Point, *geo.Point) {
+	polygon := idx.polygons[ref.Polygon]
+	return polygon[ref.Index], polygon[(ref.Index+1)%len(polygon)]
+}
+
+// Candidates returns all edges which possibly intersect the great circle arc from a to b.
+// Every edge is contained at most once.
+func (idx *Index) Candidates(a, b *geo.Point) []EdgeRef {
+	candidates := make([]EdgeRef, 0)
+	seen := make(map[EdgeRef]bool)
+	for _, cell := range idx.cellsOnArc(a, b) {
+		for _, ref := range idx.cells[cell] {
+			if !seen[ref] {
+				seen[ref] = true
+				candidates = append(candidates, ref)
+			}
+		}
+	}
+	return candidates
+}
+
+// Crosses reports whether the great circle arc from a to b intersects any coastline
+func (idx *Index) Crosses(a, b *geo.Point) bool {
+	for _, cell := range idx.cellsOnArc(a, b) {
+		for _, ref := range idx.cells[cell] {
+			from, to := idx.Edge(ref)
+			if geo.ArcsIntersect(a, b, from, to) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Crossings returns all points, where the great circle arc from a to b crosses a coastline, ordered by their distance from a
+// Only the polygons having a candidate edge are intersected with the arc.
+func (idx *Index) Crossings(a, b *geo.Point) []Crossing {
+	crossings := make([]Crossing, 0)
+	seen := make(map[int]bool)
+	for _, ref := range idx.Candidates(a, b) {
+		if seen[ref.Polygon] {
+			continue
+		}
+		seen[ref.Polygon] = true
+		for _, point := range idx.polygons[ref.Polygon].ArcIntersections(a, b) {
+			crossings = append(crossings, Crossing{Point: point, Polygon: ref.Polygon})
+		}
+	}
+	sort.Slice(crossings, func(i, j int) bool {
+		return a.Haversine(crossings[i].Point) < a.Haversine(crossings[j].Point)
+	})
+	return crossings
+}
+
+// Contains returns the index of a polygon containing the point. The second return value is false iff the point is not on land.
+func (idx *Index) Contains(p *geo.Point) (int, bool) {
+	for polygonId := range idx.polygons {
+		if idx.bboxes[polygonId].Contains(*p) && idx.polygons[polygonId].Contains(p) {
+			return polygonId, true
+		}
+	}
+	return -1, false
+}
+
+// Distance returns the distance from the point to the nearest coastline, unit meters.
+// Each edge is measured as in Polygon.BoundaryDistance, but only the edges near the point are searched.
+// Only coastlines within maxDistance are considered. The second return value is false iff there is none.
+func (idx *Index) Distance(p *geo.Point, maxDistance float64) (float64, bool) {
+	return idx.nearest(p, p, maxDistance, func(from, to *geo.Point) float64 {
+		return p.ArcDistance(from, to)
+	})
+}
+
+// ArcDistance returns the minimum distance between the great circle arc from a to b and the nearest coastline, unit meters.
+// Only coastlines within maxDistance are considered. The second return value is false iff there is none.
+func (idx *Index) ArcDistance(a, b *geo.Point, maxDistance float64) (float64, bool) {
+	return idx.nearest(a, b, maxDistance, func(from, to *geo.Point) float64 {
+		return geo.ArcToArcDistance(a, b, from, to)
+	})
+}
+
+// Search the edges in the cells near the arc from a to b for the smallest distance.
+// The search radius starts at the size of a cell and is doubled until the nearest edge is within the radius or the radius exceeds maxDistance.
+func (idx *Index) nearest(a, b *geo.Point, maxDistance float64, distance func(from, to *geo.Point) float64) (float64, bool) {
+	radius := math.Min(idx.cellSize*metersPerDegree, maxDistance)
+	for {
+		best := math.Inf(1)
+		seen := make(map[EdgeRef]bool)
+		for _, cell := range idx.cellsNearArc(a, b, radius) {
+			for _, ref := range idx.cells[cell] {
+				if seen[ref] {
+					continue
+				}
+				seen[ref] = true
+				from, to := idx.Edge(ref)
+				best = math.Min(best, distance(from, to))
+			}
+		}
+		if best <= radius {
+			return best, true
+		}
+		if radius >= maxDistance {
+			return 0, false
+		}
+		radius = math.Min(2*radius, maxDistance)
+	}
+}
+
+// Cells containing all points within the given distance (unit meters) of the great circle arc from a to b.
+// The arc is sampled in steps of half a cell, and the cells around each sample cover the distance plus half a step.
+func (idx *Index) cellsNearArc(a, b *geo.Point, radius float64) []int {
+	arcLength := a.Haversine(b)
+	steps := int(math.Ceil(2 * arcLength / (idx.cellSize * metersPerDegree)))
+	if steps < 1 {
+		steps = 1
+	}
+	cells := make([]int, 0)
+	seen := make(map[int]bool)
+	for step := 0; step <= steps; step++ {
+		sample := a.Interpolate(b, float64(step)/float64(steps))
+		for _, cell := range idx.cellsNear(sample, radius+arcLength/float64(steps)/2) {
+			if !seen[cell] {
+				seen[cell] = true
+				cells = append(cells, cell)
+			}
+		}
+	}
+	return cells
+}
+
+// Cells containing all points within the given distance (unit meters) of the point
+func (idx *Index) cellsNear(p *geo.Point, radius float64) []int {
+	dLat := radius / metersPerDegree
+	rowMin, rowMax := idx.latRow(math.Max(-90, p.Lat()-dLat)), idx.latRow(math.Min(90, p.Lat()+dLat))
+	colFrom, colCount := 0, idx.nLon
+	if maxLat := math.Abs(p.Lat()) + dLat; maxLat < 89 {
+		// circles of latitude shrink towards the poles, such that the same distance spans more degrees of longitude
+		if dLon := dLat / math.Cos(geo.Deg2Rad(maxLat)); dLon < 180 {
+			colFrom = idx.lonCol(p.Lon() - dLon)
+			colCount = (idx.lonCol(p.Lon()+dLon)-colFrom+idx.nLon)%idx.nLon + 1
+		}
+	}
+	cells := make([]int, 0, (rowMax-rowMin+1)*colCount)
+	for row := rowMin; row <= rowMax; row++ {
+		for i := 0; i < colCount; i++ {
+			cells = append(cells, row*idx.nLon+(colFrom+i)%idx.nLon)
+		}
+	}
+	return cells
+}
+
+// Cells, which the great circle arc from a to b passes through.
+// The arc is sampled in steps of half a cell. All cells within the bounding box of two consecutive samples
+// and their neighbors are reported, which covers the deviation of the arc from the straight line in between.
+func (idx *Index) cellsOnArc(a, b *geo.Point) []int {
+	arcLength := geo.Rad2Deg(math.Acos(math.Max(-1, math.Min(1, a.UnitVector().Dot(b.UnitVector())))))
+	steps := int(math.Ceil(2*arcLength/idx.cellSize)) + 1
+
+	cells := make([]int, 0)
+	seen := make(map[int]bool)
+	prev := a
+	for step := 1; step <= steps; step++ {
+		next := a.Interpolate(b, float64(step)/float64(steps))
+		if step == steps {
+			next = b
+		}
+		latMin, latMax := idx.latRow(math.Min(prev.Lat(), next.Lat()))-1, idx.latRow(math.Max(prev.Lat(), next.Lat()))+1
+		lonFrom, lonTo := idx.lonCol(prev.Lon()), idx.lonCol(next.Lon())
+		if math.Abs(next.Lon()-prev.Lon()) > 180 {
+			// the samples are on different sides of the antimeridian
+			lonFrom, lonTo = lonTo, lonFrom
+		} else if lonFrom > lonTo {
+			lonFrom, lonTo = lonTo, lonFrom
+		}
+		lonCount := (lonTo-lonFrom+idx.nLon)%idx.nLon + 3
+		for row := latMin; row <= latMax; row++ {
+			if row < 0 || row >= idx.nLat {
+				continue
+			}
+			for i := 0; i < lonCount && i < idx.nLon; i++ {
+				col := (lonFrom - 1 + i + idx.nLon) % idx.nLon
+				cell := row*idx.nLon + col
+				if !seen[cell] {
+					seen[cell] = true
+					cells = append(cells, cell)
+				}
+			}
+		}
+		prev = next
+	}
+	return cells
+}
+
+func (idx *Index) latRow(lat float64) int {
+	row := int(math.Floor((lat + 90) / idx.cellSize))
+	if row >= idx.nLat {
+		row = idx.nLat - 1
+	}
+	return row
+}
+
+func (idx *Index) lonCol(lon float64) int {
+	col := int(math.Floor((lon + 180) / idx.cellSize))
+	return (col%idx.nLon + idx.nLon) % idx.nLon
+}
@@ -0,0 +1,128 @@
+package coastline
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+)
+
+// Closed polygon approximating a circle around the center
+func circle(center *geo.Point, radius float64, n int) geo.Polygon {
+	polygon := make(geo.Polygon, 0, n+1)
+	for i := 0; i < n; i++ {
+		bearing := 2 * math.Pi * float64(i) / float64(n)
+		polygon = append(polygon, geo.NewPointFromBearing(center, bearing, radius))
+	}
+	return append(polygon, polygon[0])
+}
+
+func bruteForceCrosses(polygons []geo.Polygon, a, b *geo.Point) bool {
+	for _, polygon := range polygons {
+		for i := 0; i+1 < len(polygon); i++ {
+			if geo.ArcsIntersect(a, b, polygon[i], polygon[i+1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestIndexCrosses(t *testing.T) {
+	island := circle(geo.NewPoint(0, 0), 200000, 32)
+	index := NewIndex([]geo.Polygon{island}, 1)
+
+	if !index.Crosses(geo.NewPoint(0, -5), geo.NewPoint(0, 5)) {
+		t.Errorf("Arc through the island should cross the coastline")
+	}
+	if index.Crosses(geo.NewPoint(5, -5), geo.NewPoint(5, 5)) {
+		t.Errorf("Arc north of the island should not cross the coastline")
+	}
+}
+
+func TestIndexAntimeridian(t *testing.T) {
+	island := circle(geo.NewPoint(0, 180), 200000, 32)
+	index := NewIndex([]geo.Polygon{island}, 1)
+
+	if !index.Crosses(geo.NewPoint(0, 175), geo.NewPoint(0, -175)) {
+		t.Errorf("Arc across the antimeridian should cross the island")
+	}
+	if index.Crosses(geo.NewPoint(0, 170), geo.NewPoint(0, 175)) {
+		t.Errorf("Arc west of the island should not cross the coastline")
+	}
+}
+
+func TestIndexRandomArcs(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	randomPoint := func() *geo.Point {
+		return geo.NewPoint(rnd.Float64()*160-80, rnd.Float64()*360-180)
+	}
+	polygons := make([]geo.Polygon, 0)
+	for i := 0; i < 50; i++ {
+		polygons = append(polygons, circle(randomPoint(), 50000+rnd.Float64()*500000, 24))
+	}
+	index := NewIndex(polygons, 2)
+
+	for i := 0; i < 2000; i++ {
+		a := randomPoint()
+		// arcs of up to about 3000 km
+		b := geo.NewPointFromBearing(a, rnd.Float64()*2*math.Pi, rnd.Float64()*3000000)
+		if got, want := index.Crosses(a, b), bruteForceCrosses(polygons, a, b); got != want {
+			t.Errorf("Crosses(%v, %v) = %t, brute force reports %t", a, b, got, want)
+		}
+	}
+}
+
+func TestIndexCrossingsAndContains(t *testing.T) {
+	west, east := circle(geo.NewPoint(0, -2), 100000, 32), circle(geo.NewPoint(0, 2), 100000, 32)
+	index := NewIndex([]geo.Polygon{west, east}, 1)
+
+	crossings := index.Crossings(geo.NewPoint(0, 5), geo.NewPoint(0, -5))
+	if len(crossings) != 4 || crossings[0].Polygon != 1 || crossings[3].Polygon != 0 {
+		t.Fatalf("Expected two crossings of the eastern island followed by two of the western island, got %v", crossings)
+	}
+	if lon := crossings[0].Point.Lon(); lon < 2.8 || lon > 3 {
+		t.Errorf("First crossing at longitude %v should be the eastern shore of the eastern island", lon)
+	}
+	if len(index.Crossings(geo.NewPoint(5, -5), geo.NewPoint(5, 5))) != 0 {
+		t.Errorf("Arc north of the islands should not cross any coastline")
+	}
+
+	if polygon, ok := index.Contains(geo.NewPoint(0, 2)); !ok || polygon != 1 {
+		t.Errorf("Center of the eastern island should be contained in polygon 1, got %d (%t)", polygon, ok)
+	}
+	if _, ok := index.Contains(geo.NewPoint(0, 0)); ok {
+		t.Errorf("Point between the islands should not be on land")
+	}
+}
+
+func TestIndexDistance(t *testing.T) {
+	islands := []geo.Polygon{circle(geo.NewPoint(0, 0), 200000, 32), circle(geo.NewPoint(10, 20), 100000, 16), circle(geo.NewPoint(70, 179), 100000, 16)}
+	index := NewIndex(islands, 1)
+	rnd := rand.New(rand.NewSource(7))
+	for i := 0; i < 200; i++ {
+		a := geo.NewPoint(rnd.Float64()*100-20, rnd.Float64()*60-10)
+		b := geo.NewPoint(a.Lat()+rnd.Float64()*4-2, a.Lon()+rnd.Float64()*4-2)
+		if i%4 == 0 {
+			// near the antimeridian at a high latitude
+			a = geo.NewPoint(65+rnd.Float64()*10, 175+rnd.Float64()*10)
+		}
+		want, wantArc := math.Inf(1), math.Inf(1)
+		for _, polygon := range islands {
+			want = math.Min(want, polygon.BoundaryDistance(a))
+			for j := 0; j+1 < len(polygon); j++ {
+				wantArc = math.Min(wantArc, geo.ArcToArcDistance(a, b, polygon[j], polygon[j+1]))
+			}
+		}
+		if got, ok := index.Distance(a, 2000000); ok != (want <= 2000000) || ok && math.Abs(got-want) > 1e-6 {
+			t.Errorf("Distance of %v is %v (%v), expected %v", a, got, ok, want)
+		}
+		if got, ok := index.ArcDistance(a, b, 2000000); ok != (wantArc <= 2000000) || ok && math.Abs(got-wantArc) > 1e-6 {
+			t.Errorf("Distance of the arc from %v to %v is %v (%v), expected %v", a, b, got, ok, wantArc)
+		}
+	}
+	if _, ok := index.Distance(geo.NewPoint(-60, 100), 1000000); ok {
+		t.Errorf("Expected no coastline within 1000 km")
+	}
+}
@@ -0,0 +1,71 @@
+package geometry
+
+import "math"
+
+// Great circle arcs are the shortest connections between two points on the sphere.
+// All functions assume arcs that are shorter than half of a great circle.
+
+// Point at the given fraction of the great circle arc from first to second (spherical linear interpolation)
+func (first *Point) Interpolate(second *Point, fraction float64) *Point {
+	u, v := first.UnitVector(), second.UnitVector()
+	omega := math.Acos(math.Max(-1, math.Min(1, u.Dot(v))))
+	if omega < 1e-12 {
+		return NewPoint(first.Lat(), first.Lon())
+	}
+	sinOmega := math.Sin(omega)
+	w := u.Scale(math.Sin((1-fraction)*omega) / sinOmega).Add(v.Scale(math.Sin(fraction*omega) / sinOmega))
+	return w.Point()
+}
+
+// ArcIntersection returns the intersection point of the great circle arcs a1-a2 and b1-b2.
+// The second return value is false iff the arcs do not intersect. Arcs on the same great circle are considered not to intersect.
+func ArcIntersection(a1, a2, b1, b2 *Point) (*Point, bool) {
+	u1, u2, v1, v2 := a1.UnitVector(), a2.UnitVector(), b1.UnitVector(), b2.UnitVector()
+	// normal vectors of the planes containing the great circles
+	n, m := u1.Cross(u2), v1.Cross(v2)
+	line := n.Cross(m)
+	if line.Norm() < 1e-15 {
+		return nil, false
+	}
+	// the great circles intersect in two antipodal points
+	candidate := line.Normalize()
+	for _, c := range []Vector3{candidate, candidate.Scale(-1)} {
+		if isOnArc(c, u1, u2, n) && isOnArc(c, v1, v2, m) {
+			return c.Point(), true
+		}
+	}
+	return nil, false
+}
+
+// ArcsIntersect reports whether the great circle arcs a1-a2 and b1-b2 intersect
+func ArcsIntersect(a1, a2, b1, b2 *Point) bool {
+	_, ok := ArcIntersection(a1, a2, b1, b2)
+	return ok
+}
+
+// Checks whether c lies between u and v on the great circle with normal n = u x v, given that c lies on the great circle
+func isOnArc(c, u, v, n Vector3) bool {
+	return u.Cross(c).Dot(n) >= 0 && c.Cross(v).Dot(n) >= 0
+}
+
+// ArcDistance returns the distance from the point to the great circle arc from a to b, unit meters
+func (p *Point) ArcDistance(a, b *Point) float64 {
+	u, v, w := a.UnitVector(), b.UnitVector(), p.UnitVector()
+	n := u.Cross(v)
+	if n.Norm() > 1e-15 {
+		// the closest point of the great circle is the projection of p onto its plane
+		projection := w.Sub(n.Scale(w.Dot(n) / n.Dot(n)))
+		if projection.Norm() > 1e-15 && isOnArc(projection.Normalize(), u, v, n) {
+			return earthRadius * math.Asin(math.Min(1, math.Abs(w.Dot(n))/n.Norm()))
+		}
+	}
+	return math.Min(p.Haversine(a), p.Haversine(b))
+}
+
+// ArcToArcDistance returns the minimum distance between the great circle arcs a1-a2 and b1-b2, unit meters
+func ArcToArcDistance(a1, a2, b1, b2 *Point) float64 {
+	if ArcsIntersect(a1, a2, b1, b2) {
+		return 0
+	}
+	return math.Min(math.Min(a1.ArcDistance(b1, b2), a2.ArcDistance(b1, b2)), math.Min(b1.ArcDistance(a1, a2), b2.ArcDistance(a1, a2)))
+}
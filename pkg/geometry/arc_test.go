@@ -0,0 +1,74 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	a, b := NewPoint(0, 0), NewPoint(0, 90)
+	mid := a.Interpolate(b, 0.5)
+	if math.Abs(mid.Lat()) > 1e-9 || math.Abs(mid.Lon()-45) > 1e-9 {
+		t.Errorf("Midpoint of equator arc is %v, expected (0, 45)", mid)
+	}
+	if p := a.Interpolate(b, 0); math.Abs(p.Lon()) > 1e-9 {
+		t.Errorf("Interpolation at fraction 0 is %v", p)
+	}
+
+	// the interpolated point lies on the great circle and across the antimeridian
+	c, d := NewPoint(10, 170), NewPoint(-10, -170)
+	if p := c.Interpolate(d, 0.5); math.Abs(p.Lat()) > 1e-9 || math.Abs(math.Abs(p.Lon())-180) > 1e-9 {
+		t.Errorf("Midpoint across the antimeridian is %v", p)
+	}
+}
+
+func TestArcIntersection(t *testing.T) {
+	// crossing arcs around the origin
+	p, ok := ArcIntersection(NewPoint(-1, 0), NewPoint(1, 0), NewPoint(0, -1), NewPoint(0, 1))
+	if !ok || math.Abs(p.Lat()) > 1e-9 || math.Abs(p.Lon()) > 1e-9 {
+		t.Errorf("Expected intersection at (0, 0), got %v (%t)", p, ok)
+	}
+
+	// the great circles intersect, but not within the arcs
+	if ArcsIntersect(NewPoint(-1, 0), NewPoint(1, 0), NewPoint(0, 2), NewPoint(0, 3)) {
+		t.Errorf("Disjoint arcs should not intersect")
+	}
+	// the antipodal intersection point must not be reported
+	if ArcsIntersect(NewPoint(-1, 180), NewPoint(1, 180), NewPoint(0, -1), NewPoint(0, 1)) {
+		t.Errorf("Antipodal arcs should not intersect")
+	}
+
+	// arcs crossing the antimeridian
+	p, ok = ArcIntersection(NewPoint(0, 179), NewPoint(0, -179), NewPoint(-1, 180), NewPoint(1, 180))
+	if !ok || math.Abs(math.Abs(p.Lon())-180) > 1e-9 {
+		t.Errorf("Expected intersection on the antimeridian, got %v (%t)", p, ok)
+	}
+
+	// a great circle arc between points of the same latitude bulges towards the pole
+	if !ArcsIntersect(NewPoint(60, 0), NewPoint(60, 90), NewPoint(62, 45), NewPoint(70, 45)) {
+		t.Errorf("Great circle arc should intersect the segment north of its endpoints")
+	}
+}
+
+func TestArcDistance(t *testing.T) {
+	oneDegree := NewPoint(0, 0).Haversine(NewPoint(1, 0))
+	a, b := NewPoint(0, -1), NewPoint(0, 1)
+
+	if d := NewPoint(1, 0).ArcDistance(a, b); math.Abs(d-oneDegree) > 1e-6 {
+		t.Errorf("Distance to the equator is %v, expected %v", d, oneDegree)
+	}
+	// the closest point of the arc is its end point
+	if d, want := NewPoint(1, 5).ArcDistance(a, b), NewPoint(1, 5).Haversine(b); math.Abs(d-want) > 1e-6 {
+		t.Errorf("Distance beyond the end of the arc is %v, expected %v", d, want)
+	}
+	if d := NewPoint(0, 0.5).ArcDistance(a, b); d > 1e-6 {
+		t.Errorf("Distance of a point on the arc is %v", d)
+	}
+
+	if d := ArcToArcDistance(a, b, NewPoint(-1, 0), NewPoint(1, 0)); d != 0 {
+		t.Errorf("Distance of crossing arcs is %v", d)
+	}
+	if d := ArcToArcDistance(a, b, NewPoint(1, -1), NewPoint(1, 1)); math.Abs(d-oneDegree) > 1 {
+		t.Errorf("Distance of parallel arcs is %v, expected %v", d, oneDegree)
+	}
+}
@@ -1,6 +1,9 @@
 package geometry
 
-import "math"
+import (
+	"math"
+	"sort"
+)
 
 // based on: Some Algorithms for Polygons on a Sphere (Robert.G .Chamberlain)
 // with code here: https://github.com/kellydunn/golang-geo/blob/master/polygon.go
@@ -158,6 +161,47 @@ func (p *Polygon) Contains(point *Point) bool {
 	return contains
 }
 
+// ArcIntersections returns the points, where the great circle arc from a to b crosses the edges of the polygon,
+// ordered by their distance from a. The edge from the last to the first point is included unless the polygon is closed.
+func (p *Polygon) ArcIntersections(a, b *Point) []*Point {
+	intersections := make([]*Point, 0)
+	for i := 0; i < p.Size(); i++ {
+		from, to := p.At(i), p.At((i+1)%p.Size())
+		if from.Lat() == to.Lat() && from.Lon() == to.Lon() {
+			continue
+		}
+		if intersection, ok := ArcIntersection(a, b, from, to); ok {
+			intersections = append(intersections, intersection)
+		}
+	}
+	sort.Slice(intersections, func(i, j int) bool {
+		return a.Haversine(intersections[i]) < a.Haversine(intersections[j])
+	})
+	return intersections
+}
+
+// IntersectsArc reports whether the great circle arc from a to b intersects the polygon,
+// i.e. whether the arc crosses an edge of the polygon or lies inside of it
+func (p *Polygon) IntersectsArc(a, b *Point) bool {
+	for i := 0; i < p.Size(); i++ {
+		from, to := p.At(i), p.At((i+1)%p.Size())
+		if (from.Lat() != to.Lat() || from.Lon() != to.Lon()) && ArcsIntersect(a, b, from, to) {
+			return true
+		}
+	}
+	return p.Contains(a)
+}
+
+// BoundaryDistance returns the distance from the point to the nearest edge of the polygon, unit meters
+func (p *Polygon) BoundaryDistance(point *Point) float64 {
+	distance := math.Inf(1)
+	for i := 0; i < p.Size(); i++ {
+		from, to := p.At(i), p.At((i+1)%p.Size())
+		distance = math.Min(distance, point.ArcDistance(from, to))
+	}
+	return distance
+}
+
 func (p *Polygon) intersectsWithRaycast(point *Point, start *Point, end *Point) bool {
 	// based on paper: Some Algorithms for Polygons on a Sphere (Robert.G .Chamberlain)
 
@@ -1,6 +1,7 @@
 package geometry
 
 import (
+	"math"
 	"testing"
 )
 
@@ -125,3 +126,45 @@ func TestPointInCurvedPolygon(t *testing.T) {
 
 }
 */
+
+func TestArcIntersections(t *testing.T) {
+	// closed square between latitude and longitude 0 and 2
+	square := NewPolygon([]*Point{NewPoint(0, 0), NewPoint(0, 2), NewPoint(2, 2), NewPoint(2, 0), NewPoint(0, 0)})
+
+	// the arc along latitude 1 enters the square at longitude 0 and leaves it at longitude 2
+	intersections := square.ArcIntersections(NewPoint(1, 3), NewPoint(1, -1))
+	if len(intersections) != 2 || math.Abs(intersections[0].Lon()-2) > 1e-9 || math.Abs(intersections[1].Lon()) > 1e-9 {
+		t.Errorf("Expected intersections at longitude 2 and 0, got %v", intersections)
+	}
+	if !square.IntersectsArc(NewPoint(1, 3), NewPoint(1, -1)) {
+		t.Errorf("The arc crossing the square should intersect it")
+	}
+	// an arc inside the square does not cross any edge, but intersects the polygon
+	if len(square.ArcIntersections(NewPoint(0.5, 0.5), NewPoint(1.5, 1.5))) != 0 || !square.IntersectsArc(NewPoint(0.5, 0.5), NewPoint(1.5, 1.5)) {
+		t.Errorf("The arc inside the square should intersect it without crossings")
+	}
+	if square.IntersectsArc(NewPoint(3, 0), NewPoint(3, 2)) {
+		t.Errorf("The arc north of the square should not intersect it")
+	}
+
+	// the closing edge of an open polygon is included
+	open := NewPolygon([]*Point{NewPoint(0, 0), NewPoint(0, 2), NewPoint(2, 2), NewPoint(2, 0)})
+	if len(open.ArcIntersections(NewPoint(1, 3), NewPoint(1, -1))) != 2 {
+		t.Errorf("Expected the closing edge of the open polygon to be crossed")
+	}
+}
+
+func TestBoundaryDistance(t *testing.T) {
+	square := NewPolygon([]*Point{NewPoint(0, 0), NewPoint(0, 2), NewPoint(2, 2), NewPoint(2, 0), NewPoint(0, 0)})
+	oneDegree := NewPoint(0, 0).Haversine(NewPoint(1, 0))
+
+	if d := square.BoundaryDistance(NewPoint(1, 1)); math.Abs(d-oneDegree) > 100 {
+		t.Errorf("Distance of the center to the boundary is %v, expected about %v", d, oneDegree)
+	}
+	if d := square.BoundaryDistance(NewPoint(-1, 1)); math.Abs(d-oneDegree) > 1e-6 {
+		t.Errorf("Distance of an outside point to the boundary is %v, expected %v", d, oneDegree)
+	}
+	if d := square.BoundaryDistance(NewPoint(0, 1)); d > 1e-6 {
+		t.Errorf("Distance of a point on the boundary is %v", d)
+	}
+}
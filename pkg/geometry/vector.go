@@ -0,0 +1,54 @@
+package geometry
+
+import "math"
+
+// Vector in the three-dimensional Euclidean space
+type Vector3 [3]float64
+
+// Unit vector pointing from the center of the sphere to the point
+func (p *Point) UnitVector() Vector3 {
+	cosPhi := math.Cos(p.Phi())
+	return Vector3{cosPhi * math.Cos(p.Lambda()), cosPhi * math.Sin(p.Lambda()), math.Sin(p.Phi())}
+}
+
+// Point on the sphere in the direction of the vector
+func (v Vector3) Point() *Point {
+	lat := math.Atan2(v[2], math.Hypot(v[0], v[1]))
+	lon := math.Atan2(v[1], v[0])
+	return NewPoint(Rad2Deg(lat), Rad2Deg(lon))
+}
+
+func (v Vector3) Add(w Vector3) Vector3 {
+	return Vector3{v[0] + w[0], v[1] + w[1], v[2] + w[2]}
+}
+
+func (v Vector3) Sub(w Vector3) Vector3 {
+	return Vector3{v[0] - w[0], v[1] - w[1], v[2] - w[2]}
+}
+
+func (v Vector3) Scale(factor float64) Vector3 {
+	return Vector3{factor * v[0], factor * v[1], factor * v[2]}
+}
+
+func (v Vector3) Dot(w Vector3) float64 {
+	return v[0]*w[0] + v[1]*w[1] + v[2]*w[2]
+}
+
+func (v Vector3) Cross(w Vector3) Vector3 {
+	return Vector3{v[1]*w[2] - v[2]*w[1], v[2]*w[0] - v[0]*w[2], v[0]*w[1] - v[1]*w[0]}
+}
+
+func (v Vector3) Norm() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+func (v Vector3) Normalize() Vector3 {
+	return v.Scale(1 / v.Norm())
+}
+
+// Squared Euclidean distance between two vectors.
+// For unit vectors, the chord distance grows monotonically with the great circle distance.
+func (v Vector3) SquaredDistance(w Vector3) float64 {
+	d := v.Sub(w)
+	return d.Dot(d)
+}
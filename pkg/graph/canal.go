@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"fmt"
+
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+)
+
+// AddCanal injects an artificial waterway, which cannot be represented by the grid, e.g. a canal or a narrow strait.
+// Each waypoint of the canal becomes a new node and consecutive waypoints are connected in both directions.
+// The first and the last waypoint are connected to the closest node, which has been contained in the graph before.
+// The graph must be modified before it is converted to a static graph. Returns the ids of the nodes of the canal.
+func (alg *AdjacencyListGraph) AddCanal(waypoints []Node) []NodeId {
+	if len(waypoints) < 2 {
+		panic(fmt.Sprintf("Canal requires at least 2 waypoints, got %d", len(waypoints)))
+	}
+	if alg.NodeCount() == 0 {
+		panic("Canal cannot be connected to an empty graph")
+	}
+	entry := closestNode(alg, waypoints[0])
+	exit := closestNode(alg, waypoints[len(waypoints)-1])
+
+	canal := make([]NodeId, 0, len(waypoints))
+	for _, waypoint := range waypoints {
+		canal = append(canal, alg.NodeCount())
+		alg.AddNode(waypoint)
+	}
+	connect := func(from, to NodeId) {
+		edge := Edge{From: from, To: to, Distance: distance(alg.GetNode(from), alg.GetNode(to))}
+		alg.AddEdge(edge)
+		alg.AddEdge(edge.Invert())
+	}
+	connect(entry, canal[0])
+	for i := 1; i < len(canal); i++ {
+		connect(canal[i-1], canal[i])
+	}
+	connect(canal[len(canal)-1], exit)
+	return canal
+}
+
+// Linear scan for the node closest to the point
+func closestNode(g Graph, n Node) NodeId {
+	closest, minDistance := 0, -1
+	for id := 0; id < g.NodeCount(); id++ {
+		if d := distance(g.GetNode(id), n); minDistance < 0 || d < minDistance {
+			closest, minDistance = id, d
+		}
+	}
+	return closest
+}
+
+// Great circle distance between two nodes, unit meters
+func distance(a, b Node) int {
+	return geo.NewPoint(a.Lat, a.Lon).IntHaversine(geo.NewPoint(b.Lat, b.Lon))
+}
@@ -21,6 +21,7 @@ type EquiSphereGrid struct {
 	meshType   int // defines whether each node has at most four or six neighbors
 	NumPoints  int // actual number of points in the grid
 	points     [][]geo.Point
+	rowOffsets []int // cell id of the first point of each latitude row
 	isWater    []bool
 	grid2nodes map[IndexTupel]int
 	nodes2grid []IndexTupel
@@ -68,6 +69,7 @@ func NewEquiSphereGrid(nTarget int, meshType int, coastlines []geo.Polygon) *Equ
 func (esg *EquiSphereGrid) distributePoints() {
 	esg.NumPoints = 0
 	esg.points = make([][]geo.Point, 0)
+	esg.rowOffsets = make([]int, 0)
 
 	a := 4.0 * math.Pi / float64(esg.nTarget)
 	d := math.Sqrt(a)
@@ -76,6 +78,7 @@ func (esg *EquiSphereGrid) distributePoints() {
 	dPhi := a / dTheta
 	for m := 0; m < int(mTheta); m++ {
 		esg.points = append(esg.points, make([]geo.Point, 0))
+		esg.rowOffsets = append(esg.rowOffsets, esg.NumPoints)
 		theta := math.Pi * (float64(m) + 0.5) / mTheta
 		mPhi := math.Round(2.0 * math.Pi * math.Sin(theta) / dPhi)
 		for n := 0; n < int(mPhi); n++ {
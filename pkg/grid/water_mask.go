@@ -0,0 +1,102 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+	gr "github.com/dmholtz/osm-ship-routing/pkg/graph"
+)
+
+// A WaterMask tells whether an arbitrary point on the sphere is water according to the land / water test of a grid.
+// A point is water iff the grid point of the cell containing the point is water.
+type WaterMask interface {
+	IsWater(p *geo.Point) bool
+}
+
+// Reconstruct the grid, from which the graph has been built with the same parameters.
+// Exactly the cells containing a node of the graph are water, such that the expensive land / water test is not required.
+// Only the grid points and the land / water data are reconstructed, since a water mask requires neither the nodes nor the edges of the grid.
+func NewEquiSphereGridFromGraph(nTarget int, meshType int, g gr.Graph) *EquiSphereGrid {
+	if nTarget < 2 {
+		panic(nTarget)
+	}
+	if meshType != FOUR_NEIGHBORS && meshType != SIX_NEIGHBORS {
+		panic(fmt.Sprintf("Invalid mesh type specified: %d not in [%d, %d}]", meshType, FOUR_NEIGHBORS, SIX_NEIGHBORS))
+	}
+	esg := EquiSphereGrid{nTarget: nTarget, meshType: meshType}
+	esg.distributePoints()
+	esg.isWater = make([]bool, esg.NumPoints)
+	for i := 0; i < g.NodeCount(); i++ {
+		node := g.GetNode(i)
+		esg.isWater[esg.cellOf(geo.NewPoint(node.Lat, node.Lon))] = true
+	}
+	return &esg
+}
+
+// IsWater implements WaterMask
+func (esg *EquiSphereGrid) IsWater(p *geo.Point) bool {
+	return esg.isWater[esg.cellOf(p)]
+}
+
+// Id of the cell, whose grid point is closest to p
+func (esg *EquiSphereGrid) cellOf(p *geo.Point) int {
+	// the points of row m are at the polar angle (m + 0.5) * dTheta
+	mTheta := len(esg.points)
+	theta := math.Pi/2 - geo.Deg2Rad(p.Lat())
+	latRow := int(math.Floor(theta / math.Pi * float64(mTheta)))
+	if latRow < 0 {
+		latRow = 0
+	} else if latRow >= mTheta {
+		latRow = mTheta - 1
+	}
+	mPhi := len(esg.points[latRow])
+	phi := geo.Deg2Rad(p.Lon()) + math.Pi
+	lonCol := int(math.Round(phi/(2*math.Pi)*float64(mPhi))) % mPhi
+	if lonCol < 0 {
+		lonCol += mPhi
+	}
+	return esg.rowOffsets[latRow] + lonCol
+}
+
+// Reconstruct the grid, from which the graph has been built with the same parameters.
+// Exactly the cells containing a node of the graph are water, such that the expensive land / water test is not required.
+// Only the grid points and the land / water data are reconstructed, since a water mask requires neither the nodes nor the edges of the grid.
+func NewSimpleSphereGridFromGraph(nLon int, nLat int, g gr.Graph) *SimpleSphereGrid {
+	if nLon < 1 {
+		panic(nLon)
+	}
+	if nLat < 2 {
+		panic(nLat)
+	}
+	ssg := SimpleSphereGrid{nLon: nLon, nLat: nLat}
+	ssg.distributePoints()
+	ssg.isWater = make([]bool, len(ssg.points))
+	for i := 0; i < g.NodeCount(); i++ {
+		node := g.GetNode(i)
+		ssg.isWater[ssg.cellOf(geo.NewPoint(node.Lat, node.Lon))] = true
+	}
+	return &ssg
+}
+
+// IsWater implements WaterMask
+func (ssg *SimpleSphereGrid) IsWater(p *geo.Point) bool {
+	return ssg.isWater[ssg.cellOf(p)]
+}
+
+// Id of the cell, whose grid point is closest to p
+func (ssg *SimpleSphereGrid) cellOf(p *geo.Point) int {
+	dLat := (LatMax - LatMin) / (float64(ssg.nLat) - 1)
+	dLon := (LonMax - LonMin) / float64(ssg.nLon)
+	latRow := int(math.Round((p.Lat() - LatMin) / dLat))
+	if latRow < 0 {
+		latRow = 0
+	} else if latRow >= ssg.nLat {
+		latRow = ssg.nLat - 1
+	}
+	lonCol := int(math.Round((p.Lon()-LonMin)/dLon)) % ssg.nLon
+	if lonCol < 0 {
+		lonCol += ssg.nLon
+	}
+	return latRow*ssg.nLon + lonCol
+}
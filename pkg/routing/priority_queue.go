@@ -0,0 +1,44 @@
+package routing
+
+import gr "github.com/dmholtz/osm-ship-routing/pkg/graph"
+
+// Atomic element of the priority queue used in Theta*
+type pqItem struct {
+	id          gr.NodeId
+	distance    int // length of the path from the source node to this node (g-value)
+	priority    int // distance plus heuristic (f-value)
+	predecessor gr.NodeId
+	index       int // index of this item in the underlying slice, managed by heap.Interface
+}
+
+// Min-heap of pqItems ordered by priority, implements heap.Interface (https://pkg.go.dev/container/heap)
+type priorityQueue []*pqItem
+
+func (pq priorityQueue) Len() int {
+	return len(pq)
+}
+
+func (pq priorityQueue) Less(i, j int) bool {
+	return pq[i].priority < pq[j].priority
+}
+
+func (pq priorityQueue) Swap(i, j int) {
+	pq[i], pq[j] = pq[j], pq[i]
+	pq[i].index, pq[j].index = i, j
+}
+
+func (pq *priorityQueue) Push(item interface{}) {
+	pqItem := item.(*pqItem)
+	pqItem.index = len(*pq)
+	*pq = append(*pq, pqItem)
+}
+
+func (pq *priorityQueue) Pop() interface{} {
+	old := *pq
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*pq = old[:n-1]
+	return item
+}
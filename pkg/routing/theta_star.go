@@ -0,0 +1,121 @@
+package routing
+
+import (
+	"container/heap"
+	"math"
+
+	sp "github.com/dmholtz/graffiti/algorithms/shortest_path"
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+	gr "github.com/dmholtz/osm-ship-routing/pkg/graph"
+	"github.com/dmholtz/osm-ship-routing/pkg/grid"
+)
+
+// Default distance in meters between two points of a great circle arc, which are checked by the line of sight test
+const DefaultSamplingDistance = 5000
+
+// ThetaStarRouter computes any-angle paths on grid graphs following the Theta* algorithm by A. Nash et al.: "Theta*: Any-Angle Path Planning on Grids", 2007.
+// In contrast to A*, the successor of a node may be connected directly to the parent of the node, provided that the
+// great circle arc between them does not cross land according to the water mask.
+// The paths are thus not restricted to the edges of the graph, but consecutive nodes of a path need not be adjacent.
+// Implements the Router interface of graffiti.
+type ThetaStarRouter struct {
+	Graph gr.Graph
+	Mask  grid.WaterMask
+	// distance in meters between two points of a great circle arc, which are checked by the line of sight test.
+	// Should be smaller than the spacing of the grid, defaults to DefaultSamplingDistance iff zero.
+	SamplingDistance int
+}
+
+// String implements fmt.Stringer
+func (r ThetaStarRouter) String() string {
+	return "Theta-Star"
+}
+
+// Route computes an any-angle path from the source node to the target node.
+// The length of the path is the sum of the great circle distances between consecutive nodes of the path.
+func (r ThetaStarRouter) Route(source, target gr.NodeId, recordSearchSpace bool) sp.ShortestPathResult[int] {
+	var searchSpace []gr.NodeId = nil
+	if recordSearchSpace {
+		searchSpace = make([]gr.NodeId, 0)
+	}
+
+	points := make([]*geo.Point, r.Graph.NodeCount())
+	point := func(id gr.NodeId) *geo.Point {
+		if points[id] == nil {
+			node := r.Graph.GetNode(id)
+			points[id] = geo.NewPoint(node.Lat, node.Lon)
+		}
+		return points[id]
+	}
+	heuristic := func(id gr.NodeId) int {
+		return point(id).IntHaversine(point(target))
+	}
+
+	items := make([]*pqItem, r.Graph.NodeCount())
+	closed := make([]bool, r.Graph.NodeCount())
+	items[source] = &pqItem{id: source, distance: 0, priority: heuristic(source), predecessor: -1}
+
+	pq := make(priorityQueue, 0)
+	heap.Init(&pq)
+	heap.Push(&pq, items[source])
+
+	pqPops := 0
+	for len(pq) > 0 {
+		current := heap.Pop(&pq).(*pqItem)
+		closed[current.id] = true
+		pqPops++
+
+		if recordSearchSpace {
+			searchSpace = append(searchSpace, current.id)
+		}
+		if current.id == target {
+			break
+		}
+
+		for _, edge := range r.Graph.GetHalfEdgesFrom(current.id) {
+			successor := edge.To
+			if closed[successor] {
+				continue
+			}
+			// connect the successor directly to the parent of the current node if the parent is visible
+			predecessor, distance := current.id, current.distance+edge.Distance
+			if parent := current.predecessor; parent != -1 && r.lineOfSight(point(parent), point(successor)) {
+				predecessor, distance = parent, items[parent].distance+point(parent).IntHaversine(point(successor))
+			}
+
+			if items[successor] == nil {
+				items[successor] = &pqItem{id: successor, distance: distance, priority: distance + heuristic(successor), predecessor: predecessor}
+				heap.Push(&pq, items[successor])
+			} else if distance < items[successor].distance {
+				items[successor].distance = distance
+				items[successor].priority = distance + heuristic(successor)
+				items[successor].predecessor = predecessor
+				heap.Fix(&pq, items[successor].index)
+			}
+		}
+	}
+
+	res := sp.ShortestPathResult[int]{Length: -1, Path: make([]gr.NodeId, 0), PqPops: pqPops, SearchSpace: searchSpace}
+	if items[target] != nil && closed[target] {
+		res.Length = items[target].distance
+		for nodeId := target; nodeId != -1; nodeId = items[nodeId].predecessor {
+			res.Path = append([]gr.NodeId{nodeId}, res.Path...)
+		}
+	}
+	return res
+}
+
+// Checks whether all sampled points of the great circle arc from p to q are water
+func (r ThetaStarRouter) lineOfSight(p, q *geo.Point) bool {
+	samplingDistance := r.SamplingDistance
+	if samplingDistance <= 0 {
+		samplingDistance = DefaultSamplingDistance
+	}
+	steps := int(math.Ceil(p.Haversine(q) / float64(samplingDistance)))
+	for step := 1; step < steps; step++ {
+		if !r.Mask.IsWater(p.Interpolate(q, float64(step)/float64(steps))) {
+			return false
+		}
+	}
+	return true
+}
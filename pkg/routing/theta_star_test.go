@@ -0,0 +1,103 @@
+package routing
+
+import (
+	"testing"
+
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+	gr "github.com/dmholtz/osm-ship-routing/pkg/graph"
+	"github.com/dmholtz/osm-ship-routing/pkg/grid"
+)
+
+// Simple grid with a spacing of 5 degrees and a square island between latitude 0 and 20 and longitude 0 and 20
+func islandGrid() (*grid.SimpleSphereGrid, gr.Graph) {
+	island := geo.Polygon{geo.NewPoint(0, 0), geo.NewPoint(0, 20), geo.NewPoint(20, 20), geo.NewPoint(20, 0), geo.NewPoint(0, 0)}
+	ssg := grid.NewSimpleSphereGrid(72, 37, []geo.Polygon{island})
+	return ssg, gr.NewAdjacencyArrayFromGraph(ssg.ToGraph())
+}
+
+func nodeAt(t *testing.T, g gr.Graph, lat, lon float64) gr.NodeId {
+	for i := 0; i < g.NodeCount(); i++ {
+		if node := g.GetNode(i); node.Lat == lat && node.Lon == lon {
+			return i
+		}
+	}
+	t.Fatalf("No node at (%v, %v)", lat, lon)
+	return -1
+}
+
+func TestThetaStarOpenWater(t *testing.T) {
+	ssg, g := islandGrid()
+	router := ThetaStarRouter{Graph: g, Mask: ssg, SamplingDistance: 50000}
+	source, target := nodeAt(t, g, -30, -60), nodeAt(t, g, -10, -20)
+
+	res := router.Route(source, target, false)
+	if len(res.Path) != 2 || res.Path[0] != source || res.Path[1] != target {
+		t.Errorf("Expected a direct path, got %v", res.Path)
+	}
+	if want := geo.NewPoint(-30, -60).IntHaversine(geo.NewPoint(-10, -20)); res.Length != want {
+		t.Errorf("Length is %d, expected %d", res.Length, want)
+	}
+}
+
+func TestThetaStarAroundIsland(t *testing.T) {
+	ssg, g := islandGrid()
+	router := ThetaStarRouter{Graph: g, Mask: ssg, SamplingDistance: 50000}
+	source, target := nodeAt(t, g, 10, -20), nodeAt(t, g, 10, 40)
+
+	res := router.Route(source, target, true)
+	if res.Length < 0 || res.Path[0] != source || res.Path[len(res.Path)-1] != target {
+		t.Fatalf("Unexpected result %v", res)
+	}
+	length := 0
+	for i := 1; i < len(res.Path); i++ {
+		p, q := g.GetNode(res.Path[i-1]), g.GetNode(res.Path[i])
+		from, to := geo.NewPoint(p.Lat, p.Lon), geo.NewPoint(q.Lat, q.Lon)
+		if !router.lineOfSight(from, to) {
+			t.Errorf("No line of sight between consecutive nodes %v and %v", p, q)
+		}
+		length += from.IntHaversine(to)
+	}
+	if length != res.Length {
+		t.Errorf("Length is %d, but the path has length %d", res.Length, length)
+	}
+	if direct := geo.NewPoint(10, -20).IntHaversine(geo.NewPoint(10, 40)); res.Length <= direct {
+		t.Errorf("Path of length %d does not go around the island, direct distance %d", res.Length, direct)
+	}
+	if len(res.SearchSpace) != res.PqPops {
+		t.Errorf("Search space contains %d nodes, but %d nodes have been settled", len(res.SearchSpace), res.PqPops)
+	}
+}
+
+func TestThetaStarUnreachable(t *testing.T) {
+	ssg, g := islandGrid()
+	// isolated node on the island
+	alg := &gr.AdjacencyListGraph{}
+	for i := 0; i < g.NodeCount(); i++ {
+		alg.AddNode(g.GetNode(i))
+	}
+	for i := 0; i < g.NodeCount(); i++ {
+		for _, edge := range g.GetHalfEdgesFrom(i) {
+			alg.AddEdge(gr.Edge{From: i, To: edge.To, Distance: edge.Distance})
+		}
+	}
+	alg.AddNode(gr.Node{Lat: 10, Lon: 10})
+	router := ThetaStarRouter{Graph: alg, Mask: ssg, SamplingDistance: 50000}
+
+	if res := router.Route(0, alg.NodeCount()-1, false); res.Length != -1 || len(res.Path) != 0 {
+		t.Errorf("Expected no path, got %v", res)
+	}
+}
+
+func TestGridFromGraph(t *testing.T) {
+	ssg, g := islandGrid()
+	reconstructed := grid.NewSimpleSphereGridFromGraph(72, 37, g)
+
+	for lat := -90.0; lat <= 90; lat += 2.5 {
+		for lon := -180.0; lon < 180; lon += 2.5 {
+			p := geo.NewPoint(lat, lon)
+			if ssg.IsWater(p) != reconstructed.IsWater(p) {
+				t.Errorf("Reconstructed water mask differs at %v", p)
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package routing
+
+import (
+	"container/heap"
+	"time"
+
+	sp "github.com/dmholtz/graffiti/algorithms/shortest_path"
+	gr "github.com/dmholtz/osm-ship-routing/pkg/graph"
+)
+
+// TraversalTimer computes the time required to travel an edge of the given length (unit meters) when entering it at the departure time.
+// ok is false iff the edge cannot be travelled at that time. Implemented by weather.Conditions.
+type TraversalTimer interface {
+	TraversalTime(from, to gr.Node, distance int, departure time.Time) (duration time.Duration, ok bool)
+}
+
+// TimeDependentRouter computes the path of the earliest arrival at the target by the time-dependent Dijkstra algorithm,
+// which settles the nodes in the order of their arrival times and evaluates the traversal time of each edge at the arrival time at its start node.
+// The arrival times are optimal if the traversal times satisfy the FIFO property, i.e. departing later never results in an earlier arrival,
+// which holds for fields changing slowly compared to the traversal times of the edges.
+// Implements the Router interface of graffiti.
+type TimeDependentRouter struct {
+	Graph     gr.Graph
+	Timer     TraversalTimer
+	Departure time.Time // departure time at the source node
+}
+
+// String implements fmt.Stringer
+func (r TimeDependentRouter) String() string {
+	return "Time-Dependent-Dijkstra"
+}
+
+// Route computes the path of the earliest arrival from the source node to the target node.
+// The length of the result is the travel time, unit seconds, such that the arrival is Departure plus Length seconds.
+func (r TimeDependentRouter) Route(source, target gr.NodeId, recordSearchSpace bool) sp.ShortestPathResult[int] {
+	var searchSpace []gr.NodeId = nil
+	if recordSearchSpace {
+		searchSpace = make([]gr.NodeId, 0)
+	}
+
+	// the distances of the items are travel times, unit milliseconds
+	items := make([]*pqItem, r.Graph.NodeCount())
+	closed := make([]bool, r.Graph.NodeCount())
+	items[source] = &pqItem{id: source, distance: 0, priority: 0, predecessor: -1}
+
+	pq := make(priorityQueue, 0)
+	heap.Init(&pq)
+	heap.Push(&pq, items[source])
+
+	pqPops := 0
+	for len(pq) > 0 {
+		current := heap.Pop(&pq).(*pqItem)
+		closed[current.id] = true
+		pqPops++
+
+		if recordSearchSpace {
+			searchSpace = append(searchSpace, current.id)
+		}
+		if current.id == target {
+			break
+		}
+
+		arrival := r.Departure.Add(time.Duration(current.distance) * time.Millisecond)
+		from := r.Graph.GetNode(current.id)
+		for _, edge := range r.Graph.GetHalfEdgesFrom(current.id) {
+			successor := edge.To
+			if closed[successor] {
+				continue
+			}
+			duration, ok := r.Timer.TraversalTime(from, r.Graph.GetNode(successor), edge.Distance, arrival)
+			if !ok {
+				continue
+			}
+			travelTime := current.distance + int(duration.Milliseconds())
+
+			if items[successor] == nil {
+				items[successor] = &pqItem{id: successor, distance: travelTime, priority: travelTime, predecessor: current.id}
+				heap.Push(&pq, items[successor])
+			} else if travelTime < items[successor].distance {
+				items[successor].distance = travelTime
+				items[successor].priority = travelTime
+				items[successor].predecessor = current.id
+				heap.Fix(&pq, items[successor].index)
+			}
+		}
+	}
+
+	res := sp.ShortestPathResult[int]{Length: -1, Path: make([]gr.NodeId, 0), PqPops: pqPops, SearchSpace: searchSpace}
+	if items[target] != nil && closed[target] {
+		res.Length = (items[target].distance + 500) / 1000
+		for nodeId := target; nodeId != -1; nodeId = items[nodeId].predecessor {
+			res.Path = append([]gr.NodeId{nodeId}, res.Path...)
+		}
+	}
+	return res
+}
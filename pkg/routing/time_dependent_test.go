@@ -0,0 +1,110 @@
+package routing
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+	gr "github.com/dmholtz/osm-ship-routing/pkg/graph"
+	"github.com/dmholtz/osm-ship-routing/pkg/weather"
+)
+
+var testDeparture = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// Diamond of a source node (0, 0), a northern node (1, 1), a southern node (-1, 1) and a target node (0, 2),
+// such that the northern and the southern path from the source to the target have the same length
+func diamondGraph() gr.Graph {
+	alg := &gr.AdjacencyListGraph{}
+	nodes := []gr.Node{{Lat: 0, Lon: 0}, {Lat: 1, Lon: 1}, {Lat: -1, Lon: 1}, {Lat: 0, Lon: 2}}
+	for _, node := range nodes {
+		alg.AddNode(node)
+	}
+	for _, pair := range [][2]gr.NodeId{{0, 1}, {0, 2}, {1, 3}, {2, 3}} {
+		p, q := nodes[pair[0]], nodes[pair[1]]
+		distance := geo.NewPoint(p.Lat, p.Lon).IntHaversine(geo.NewPoint(q.Lat, q.Lon))
+		alg.AddEdge(gr.Edge{From: pair[0], To: pair[1], Distance: distance})
+		alg.AddEdge(gr.Edge{From: pair[1], To: pair[0], Distance: distance})
+	}
+	return alg
+}
+
+// Westward current of 3 m/s north of latitude 0.5 in the first frame and south of latitude -0.5 in the second frame three days later
+func shiftingCurrents() *weather.Field {
+	field := weather.NewField(-2, -1, 0.5, 0.5, 9, 9, testDeparture, 72*time.Hour, 2)
+	for i := 0; i < field.LatCount; i++ {
+		for j := 0; j < field.LonCount; j++ {
+			if lat := field.LatMin + float64(i)*field.LatStep; lat >= 0.5 {
+				field.Set(0, i, j, weather.Vector{U: -3})
+			} else if lat <= -0.5 {
+				field.Set(1, i, j, weather.Vector{U: -3})
+			}
+		}
+	}
+	return field
+}
+
+func TestTimeDependentStillWater(t *testing.T) {
+	_, g := islandGrid()
+	conditions := weather.Conditions{Polar: weather.ConstantPolar(10)}
+	router := TimeDependentRouter{Graph: g, Timer: conditions, Departure: testDeparture}
+	source, target := nodeAt(t, g, 10, -20), nodeAt(t, g, 10, 40)
+
+	res := router.Route(source, target, true)
+	if res.Length < 0 || res.Path[0] != source || res.Path[len(res.Path)-1] != target {
+		t.Fatalf("Unexpected result %v", res)
+	}
+	length := 0
+	for i := 1; i < len(res.Path); i++ {
+		for _, edge := range g.GetHalfEdgesFrom(res.Path[i-1]) {
+			if edge.To == res.Path[i] {
+				length += edge.Distance
+			}
+		}
+	}
+	if want := float64(length) / (10 * weather.Knot); math.Abs(float64(res.Length)-want) > float64(len(res.Path)) {
+		t.Errorf("Travel time is %d s, expected %v s for a path of %d m at 10 knots", res.Length, want, length)
+	}
+	if len(res.SearchSpace) != res.PqPops {
+		t.Errorf("Search space contains %d nodes, but %d nodes have been settled", len(res.SearchSpace), res.PqPops)
+	}
+}
+
+func TestTimeDependentCurrents(t *testing.T) {
+	g := diamondGraph()
+	conditions := weather.Conditions{Polar: weather.ConstantPolar(10), Currents: shiftingCurrents()}
+	router := TimeDependentRouter{Graph: g, Timer: conditions, Departure: testDeparture}
+	still := TimeDependentRouter{Graph: g, Timer: weather.Conditions{Polar: weather.ConstantPolar(10)}, Departure: testDeparture}.Route(0, 3, false)
+
+	// the adverse current is in the north at the departure
+	early := router.Route(0, 3, false)
+	if len(early.Path) != 3 || early.Path[1] != 2 {
+		t.Errorf("Expected the southern path, got %v", early.Path)
+	}
+	// and has moved to the south after three days
+	router.Departure = testDeparture.Add(72 * time.Hour)
+	late := router.Route(0, 3, false)
+	if len(late.Path) != 3 || late.Path[1] != 1 {
+		t.Errorf("Expected the northern path, got %v", late.Path)
+	}
+	if early.Length <= still.Length || late.Length != still.Length {
+		t.Errorf("Expected the travel time of %d s in still water to increase only for the early departure, got %d s and %d s", still.Length, early.Length, late.Length)
+	}
+}
+
+func TestTimeDependentNoHeadway(t *testing.T) {
+	g := diamondGraph()
+	// a westward current of 6 m/s exceeds the speed of the vessel everywhere
+	currents := weather.NewField(-2, -1, 0.5, 0.5, 9, 9, testDeparture, time.Hour, 1)
+	for i := range currents.Vectors {
+		currents.Vectors[i] = weather.Vector{U: -6}
+	}
+	router := TimeDependentRouter{Graph: g, Timer: weather.Conditions{Polar: weather.ConstantPolar(10), Currents: currents}, Departure: testDeparture}
+
+	if res := router.Route(0, 3, false); res.Length != -1 || len(res.Path) != 0 {
+		t.Errorf("Expected no path, got %v", res)
+	}
+	if res := router.Route(3, 0, false); res.Length < 0 {
+		t.Errorf("Expected a path with the current")
+	}
+}
@@ -0,0 +1,64 @@
+package weather
+
+import (
+	"math"
+	"time"
+
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+	gr "github.com/dmholtz/osm-ship-routing/pkg/graph"
+)
+
+// One knot is one nautical mile per hour, unit meters per second
+const Knot = 1852.0 / 3600
+
+// Conditions determine the speed over ground of a vessel from its polar, the winds and the currents
+type Conditions struct {
+	Polar    Polar
+	Winds    *Field // nil for calm conditions
+	Currents *Field // nil for still water
+}
+
+// SpeedOverGround of a vessel keeping the course (unit degree) at the given position and time, unit meters per second.
+// The vessel sails with the speed through water given by its polar for the true wind relative to the course
+// and steers against the cross component of the current, such that it does not drift off the course.
+// The speed is not positive iff the vessel cannot make headway on the course.
+func (c Conditions) SpeedOverGround(p *geo.Point, course float64, t time.Time) float64 {
+	sin, cos := math.Sincos(geo.Deg2Rad(course))
+	windSpeed, windAngle := 0.0, 0.0
+	if c.Winds != nil {
+		wind := c.Winds.At(p.Lat(), p.Lon(), t)
+		if windSpeed = wind.Speed(); windSpeed > 0 {
+			// angle between the course and the direction the wind is coming from
+			windAngle = geo.Rad2Deg(math.Acos(math.Max(-1, math.Min(1, -(sin*wind.U+cos*wind.V)/windSpeed))))
+		}
+	}
+	speed := c.Polar.Speed(windSpeed/Knot, windAngle) * Knot
+	if c.Currents == nil {
+		return speed
+	}
+	current := c.Currents.At(p.Lat(), p.Lon(), t)
+	along, across := sin*current.U+cos*current.V, sin*current.V-cos*current.U
+	if math.Abs(across) >= speed {
+		return 0
+	}
+	return math.Sqrt(speed*speed-across*across) + along
+}
+
+// TraversalTime is the time required to travel the great circle arc of an edge of the given length (unit meters),
+// when entering the edge at the departure time. ok is false iff the vessel cannot make headway along the edge.
+// The edge is split in two halves, each of which is travelled at the speed over ground at its start point and start time.
+func (c Conditions) TraversalTime(from, to gr.Node, distance int, departure time.Time) (time.Duration, bool) {
+	p, q := geo.NewPoint(from.Lat, from.Lon), geo.NewPoint(to.Lat, to.Lon)
+	mid := p.Midpoint(q)
+	half := float64(distance) / 2
+	first := c.SpeedOverGround(p, p.Bearing(q), departure)
+	if first <= 0 {
+		return 0, false
+	}
+	firstDuration := time.Duration(half / first * float64(time.Second))
+	second := c.SpeedOverGround(mid, mid.Bearing(q), departure.Add(firstDuration))
+	if second <= 0 {
+		return 0, false
+	}
+	return firstDuration + time.Duration(half/second*float64(time.Second)), true
+}
@@ -0,0 +1,214 @@
+package weather
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// Magic number and version of the binary field format
+const (
+	fieldMagic   = "OSWF"
+	fieldVersion = 1
+)
+
+// Vector in the local tangent plane of a point, unit meters per second
+type Vector struct {
+	U float64 // eastward component
+	V float64 // northward component
+}
+
+// Speed is the magnitude of the vector, unit meters per second
+func (v Vector) Speed() float64 {
+	return math.Hypot(v.U, v.V)
+}
+
+// Field is a time-indexed vector field on a regular latitude-longitude grid, e.g. surface currents or winds.
+// Vectors point in the direction the water or air is moving to.
+// Between grid points and frames, the field is interpolated linearly. Outside of the grid, the field is zero,
+// and before the first or after the last frame, the first or last frame applies.
+type Field struct {
+	LatMin, LonMin   float64 // position of the south-western grid point, unit degree
+	LatStep, LonStep float64 // spacing of the grid, unit degree
+	LatCount         int
+	LonCount         int
+	Start            time.Time     // valid time of the first frame
+	Interval         time.Duration // time between two consecutive frames
+	// vectors of all frames in time-major, then latitude-major order, i.e. the vector of grid point (i, j) of frame k
+	// is at index (k*LatCount+i)*LonCount+j
+	Vectors []Vector
+}
+
+// Header of the binary field format, all values are little endian
+type fieldHeader struct {
+	Magic      [4]byte
+	Version    uint32
+	LatCount   uint32
+	LonCount   uint32
+	FrameCount uint32
+	LatMin     float64
+	LonMin     float64
+	LatStep    float64
+	LonStep    float64
+	Start      int64 // unix time, unit seconds
+	Interval   int64 // unit seconds
+}
+
+// NewField creates a field of the given grid and frames, whose vectors are zero
+func NewField(latMin, lonMin, latStep, lonStep float64, latCount, lonCount int, start time.Time, interval time.Duration, frameCount int) *Field {
+	return &Field{LatMin: latMin, LonMin: lonMin, LatStep: latStep, LonStep: lonStep, LatCount: latCount, LonCount: lonCount,
+		Start: start, Interval: interval, Vectors: make([]Vector, frameCount*latCount*lonCount)}
+}
+
+// FrameCount is the number of time steps of the field
+func (f *Field) FrameCount() int {
+	if f.LatCount == 0 || f.LonCount == 0 {
+		return 0
+	}
+	return len(f.Vectors) / (f.LatCount * f.LonCount)
+}
+
+// Set the vector of grid point (i, j) of frame k
+func (f *Field) Set(k, i, j int, v Vector) {
+	f.Vectors[(k*f.LatCount+i)*f.LonCount+j] = v
+}
+
+func (f *Field) get(k, i, j int) Vector {
+	return f.Vectors[(k*f.LatCount+i)*f.LonCount+j]
+}
+
+// global reports whether the grid wraps around the antimeridian
+func (f *Field) global() bool {
+	return float64(f.LonCount)*f.LonStep >= 360
+}
+
+// At interpolates the field at the given position and time
+func (f *Field) At(lat, lon float64, t time.Time) Vector {
+	frameCount := f.FrameCount()
+	if frameCount == 0 {
+		return Vector{}
+	}
+	k, weight := 0, 0.0
+	if frameCount > 1 && f.Interval > 0 && t.After(f.Start) {
+		frame := float64(t.Sub(f.Start)) / float64(f.Interval)
+		if frame >= float64(frameCount-1) {
+			k = frameCount - 1
+		} else {
+			k = int(frame)
+			weight = frame - float64(k)
+		}
+	}
+	v := f.atFrame(k, lat, lon)
+	if weight > 0 {
+		w := f.atFrame(k+1, lat, lon)
+		v = Vector{U: (1-weight)*v.U + weight*w.U, V: (1-weight)*v.V + weight*w.V}
+	}
+	return v
+}
+
+// Bilinear interpolation of frame k
+func (f *Field) atFrame(k int, lat, lon float64) Vector {
+	y := (lat - f.LatMin) / f.LatStep
+	x := (lon - f.LonMin) / f.LonStep
+	if f.global() {
+		x = math.Mod(x, float64(f.LonCount))
+		if x < 0 {
+			x += float64(f.LonCount)
+		}
+	}
+	if y < 0 || y > float64(f.LatCount-1) || x < 0 || (!f.global() && x > float64(f.LonCount-1)) {
+		return Vector{}
+	}
+	i, j := int(y), int(x)
+	dy, dx := y-float64(i), x-float64(j)
+	i1, j1 := i+1, j+1
+	if i1 == f.LatCount {
+		i1 = i
+	}
+	if j1 == f.LonCount {
+		if f.global() {
+			j1 = 0
+		} else {
+			j1 = j
+		}
+	}
+	v00, v01, v10, v11 := f.get(k, i, j), f.get(k, i, j1), f.get(k, i1, j), f.get(k, i1, j1)
+	return Vector{
+		U: (1-dy)*((1-dx)*v00.U+dx*v01.U) + dy*((1-dx)*v10.U+dx*v11.U),
+		V: (1-dy)*((1-dx)*v00.V+dx*v01.V) + dy*((1-dx)*v10.V+dx*v11.V),
+	}
+}
+
+// Write encodes the field in the binary format: a header followed by the eastward and northward components of all vectors
+// as pairs of little endian float32 values in the order of Vectors.
+func (f *Field) Write(w io.Writer) error {
+	header := fieldHeader{Version: fieldVersion, LatCount: uint32(f.LatCount), LonCount: uint32(f.LonCount), FrameCount: uint32(f.FrameCount()),
+		LatMin: f.LatMin, LonMin: f.LonMin, LatStep: f.LatStep, LonStep: f.LonStep, Start: f.Start.Unix(), Interval: int64(f.Interval / time.Second)}
+	copy(header.Magic[:], fieldMagic)
+	writer := bufio.NewWriter(w)
+	if err := binary.Write(writer, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	values := make([]float32, 0, 2*len(f.Vectors))
+	for _, v := range f.Vectors {
+		values = append(values, float32(v.U), float32(v.V))
+	}
+	if err := binary.Write(writer, binary.LittleEndian, values); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// Read decodes a field in the binary format written by Write
+func Read(r io.Reader) (*Field, error) {
+	reader := bufio.NewReader(r)
+	var header fieldHeader
+	if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("invalid field header: %w", err)
+	}
+	if string(header.Magic[:]) != fieldMagic {
+		return nil, errors.New("not a field file")
+	}
+	if header.Version != fieldVersion {
+		return nil, fmt.Errorf("unsupported field version %d", header.Version)
+	}
+	if header.LatCount == 0 || header.LonCount == 0 || header.FrameCount == 0 {
+		return nil, errors.New("field must contain at least one grid point and frame")
+	}
+	if !(header.LatStep > 0) || !(header.LonStep > 0) || header.Interval < 0 {
+		return nil, fmt.Errorf("invalid spacing %v, %v or interval %d", header.LatStep, header.LonStep, header.Interval)
+	}
+	count := uint64(header.FrameCount) * uint64(header.LatCount) * uint64(header.LonCount)
+	if count > math.MaxInt32 {
+		return nil, fmt.Errorf("field of %d vectors is too large", count)
+	}
+	values := make([]float32, 2*count)
+	if err := binary.Read(reader, binary.LittleEndian, values); err != nil {
+		return nil, fmt.Errorf("truncated field data: %w", err)
+	}
+	field := NewField(header.LatMin, header.LonMin, header.LatStep, header.LonStep, int(header.LatCount), int(header.LonCount),
+		time.Unix(header.Start, 0).UTC(), time.Duration(header.Interval)*time.Second, int(header.FrameCount))
+	for i := range field.Vectors {
+		field.Vectors[i] = Vector{U: float64(values[2*i]), V: float64(values[2*i+1])}
+	}
+	return field, nil
+}
+
+// Load reads a field from a file in the binary format
+func Load(filename string) (*Field, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	field, err := Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return field, nil
+}
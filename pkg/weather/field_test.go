@@ -0,0 +1,97 @@
+package weather
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+var testStart = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// Field on a grid of 3x3 points with a spacing of 1 degree, whose eastward component is the longitude offset
+// in the first frame and twice the longitude offset in the second frame six hours later
+func testField() *Field {
+	field := NewField(10, 20, 1, 1, 3, 3, testStart, 6*time.Hour, 2)
+	for k := 0; k < 2; k++ {
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				field.Set(k, i, j, Vector{U: float64((k + 1) * j), V: float64(i)})
+			}
+		}
+	}
+	return field
+}
+
+func TestFieldAt(t *testing.T) {
+	field := testField()
+	cases := []struct {
+		lat, lon float64
+		t        time.Time
+		want     Vector
+	}{
+		{10, 20, testStart, Vector{U: 0, V: 0}},
+		{11.5, 21.5, testStart, Vector{U: 1.5, V: 1.5}},
+		{12, 22, testStart, Vector{U: 2, V: 2}},
+		{11, 21, testStart.Add(3 * time.Hour), Vector{U: 1.5, V: 1}},
+		{11, 21, testStart.Add(-time.Hour), Vector{U: 1, V: 1}},
+		{11, 21, testStart.Add(24 * time.Hour), Vector{U: 2, V: 1}},
+		{9, 21, testStart, Vector{}},
+		{11, 23, testStart, Vector{}},
+	}
+	for _, c := range cases {
+		if got := field.At(c.lat, c.lon, c.t); math.Abs(got.U-c.want.U) > 1e-9 || math.Abs(got.V-c.want.V) > 1e-9 {
+			t.Errorf("At(%v, %v, %v) = %v, expected %v", c.lat, c.lon, c.t, got, c.want)
+		}
+	}
+}
+
+func TestGlobalFieldWrapsAround(t *testing.T) {
+	field := NewField(-10, -180, 10, 90, 3, 4, testStart, 0, 1)
+	field.Set(0, 1, 3, Vector{U: 4}) // longitude 90
+	field.Set(0, 1, 0, Vector{U: 0}) // longitude -180
+
+	if got := field.At(0, 135, testStart); math.Abs(got.U-2) > 1e-9 {
+		t.Errorf("Expected interpolation across the antimeridian, got %v", got)
+	}
+	if got := field.At(0, 450, testStart); math.Abs(got.U-4) > 1e-9 {
+		t.Errorf("Expected longitude 450 to equal 90, got %v", got)
+	}
+}
+
+func TestFieldRoundTrip(t *testing.T) {
+	field := testField()
+	var buf bytes.Buffer
+	if err := field.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.LatMin != field.LatMin || decoded.LonStep != field.LonStep || decoded.LatCount != 3 || decoded.FrameCount() != 2 ||
+		!decoded.Start.Equal(field.Start) || decoded.Interval != field.Interval {
+		t.Errorf("Unexpected grid of the decoded field %+v", decoded)
+	}
+	for i, v := range field.Vectors {
+		if decoded.Vectors[i] != v {
+			t.Errorf("Vector %d: expected %v, got %v", i, v, decoded.Vectors[i])
+		}
+	}
+}
+
+func TestReadInvalidField(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testField().Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	if _, err := Read(bytes.NewReader(data[:len(data)-4])); err == nil {
+		t.Errorf("Expected an error for truncated data")
+	}
+	wrongMagic := append([]byte("GRIB"), data[4:]...)
+	if _, err := Read(bytes.NewReader(wrongMagic)); err == nil {
+		t.Errorf("Expected an error for a wrong magic number")
+	}
+}
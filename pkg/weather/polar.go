@@ -0,0 +1,100 @@
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+)
+
+// Polar is the speed polar of a vessel, i.e. its speed through water depending on the true wind speed and the true wind angle.
+// Speeds between the entries of the table are interpolated linearly, and wind speeds and angles beyond the table are clamped.
+type Polar struct {
+	WindSpeeds []float64   `json:"wind_speeds"` // ascending, unit knots
+	WindAngles []float64   `json:"wind_angles"` // ascending in [0, 180], where 0 is a head wind, unit degree
+	Speeds     [][]float64 `json:"speeds"`      // speed at WindAngles[i] and WindSpeeds[j] is Speeds[i][j], unit knots
+}
+
+// ConstantPolar is the polar of a vessel, whose speed through water does not depend on the wind
+func ConstantPolar(speed float64) Polar {
+	return Polar{WindSpeeds: []float64{0}, WindAngles: []float64{0}, Speeds: [][]float64{{speed}}}
+}
+
+// LoadPolar reads a polar from a JSON file
+func LoadPolar(filename string) (Polar, error) {
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return Polar{}, err
+	}
+	var polar Polar
+	if err := json.Unmarshal(bytes, &polar); err != nil {
+		return Polar{}, fmt.Errorf("invalid polar file %s: %w", filename, err)
+	}
+	if err := polar.Validate(); err != nil {
+		return Polar{}, fmt.Errorf("invalid polar file %s: %w", filename, err)
+	}
+	return polar, nil
+}
+
+// Validate checks the dimensions of the table and the order of the wind speeds and angles
+func (p Polar) Validate() error {
+	if len(p.WindSpeeds) == 0 || len(p.WindAngles) == 0 {
+		return errors.New("polar requires at least one wind speed and wind angle")
+	}
+	if len(p.Speeds) != len(p.WindAngles) {
+		return fmt.Errorf("polar has %d rows of speeds, expected one per wind angle", len(p.Speeds))
+	}
+	for i, row := range p.Speeds {
+		if len(row) != len(p.WindSpeeds) {
+			return fmt.Errorf("row %d of the polar has %d speeds, expected one per wind speed", i, len(row))
+		}
+		for _, speed := range row {
+			if math.IsNaN(speed) || speed < 0 {
+				return fmt.Errorf("speed %v of the polar must not be negative", speed)
+			}
+		}
+	}
+	for i := 1; i < len(p.WindSpeeds); i++ {
+		if !(p.WindSpeeds[i] > p.WindSpeeds[i-1]) {
+			return errors.New("wind speeds of the polar must be ascending")
+		}
+	}
+	for i, angle := range p.WindAngles {
+		if angle < 0 || angle > 180 || (i > 0 && !(angle > p.WindAngles[i-1])) {
+			return errors.New("wind angles of the polar must be ascending in [0, 180]")
+		}
+	}
+	return nil
+}
+
+// Speed through water at the given true wind speed (unit knots) and true wind angle (unit degree)
+func (p Polar) Speed(windSpeed, windAngle float64) float64 {
+	windAngle = math.Abs(math.Mod(windAngle, 360))
+	if windAngle > 180 {
+		windAngle = 360 - windAngle
+	}
+	i, di := interpolationIndex(p.WindAngles, windAngle)
+	j, dj := interpolationIndex(p.WindSpeeds, windSpeed)
+	i1, j1 := i, j
+	if di > 0 {
+		i1 = i + 1
+	}
+	if dj > 0 {
+		j1 = j + 1
+	}
+	return (1-di)*((1-dj)*p.Speeds[i][j]+dj*p.Speeds[i][j1]) + di*((1-dj)*p.Speeds[i1][j]+dj*p.Speeds[i1][j1])
+}
+
+// Index of the last value not greater than x and the fraction of the way to the next value, clamped to the values
+func interpolationIndex(values []float64, x float64) (int, float64) {
+	if x <= values[0] {
+		return 0, 0
+	}
+	for i := 1; i < len(values); i++ {
+		if x < values[i] {
+			return i - 1, (x - values[i-1]) / (values[i] - values[i-1])
+		}
+	}
+	return len(values) - 1, 0
+}
@@ -0,0 +1,101 @@
+package weather
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	geo "github.com/dmholtz/osm-ship-routing/pkg/geometry"
+	gr "github.com/dmholtz/osm-ship-routing/pkg/graph"
+)
+
+// Polar of a motor vessel, which slows down in head winds
+var testPolar = Polar{
+	WindSpeeds: []float64{0, 20, 40},
+	WindAngles: []float64{0, 90, 180},
+	Speeds: [][]float64{
+		{12, 10, 6},
+		{12, 11, 9},
+		{12, 12, 12},
+	},
+}
+
+func TestPolarSpeed(t *testing.T) {
+	if err := testPolar.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		windSpeed, windAngle, want float64
+	}{
+		{0, 0, 12},
+		{20, 0, 10},
+		{30, 0, 8},
+		{20, 45, 10.5},
+		{20, 315, 10.5},
+		{60, 0, 6},
+		{40, 270, 9},
+	}
+	for _, c := range cases {
+		if got := testPolar.Speed(c.windSpeed, c.windAngle); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("Speed(%v, %v) = %v, expected %v", c.windSpeed, c.windAngle, got, c.want)
+		}
+	}
+}
+
+func TestPolarValidate(t *testing.T) {
+	invalid := []Polar{
+		{},
+		{WindSpeeds: []float64{0, 10}, WindAngles: []float64{0}, Speeds: [][]float64{{10}}},
+		{WindSpeeds: []float64{10, 0}, WindAngles: []float64{0}, Speeds: [][]float64{{10, 10}}},
+		{WindSpeeds: []float64{0}, WindAngles: []float64{200}, Speeds: [][]float64{{10}}},
+		{WindSpeeds: []float64{0}, WindAngles: []float64{0}, Speeds: [][]float64{{-1}}},
+	}
+	for _, polar := range invalid {
+		if err := polar.Validate(); err == nil {
+			t.Errorf("Expected polar %v to be invalid", polar)
+		}
+	}
+}
+
+func TestSpeedOverGround(t *testing.T) {
+	p := geo.NewPoint(0, 0)
+	uniform := func(v Vector) *Field {
+		field := NewField(-10, -10, 10, 10, 3, 3, testStart, 0, 1)
+		for i := range field.Vectors {
+			field.Vectors[i] = v
+		}
+		return field
+	}
+	// head wind of 20 knots on an eastward course
+	headWind := Conditions{Polar: testPolar, Winds: uniform(Vector{U: -20 * Knot})}
+	if got := headWind.SpeedOverGround(p, 90, testStart); math.Abs(got-10*Knot) > 1e-9 {
+		t.Errorf("Expected 10 knots in a head wind, got %v knots", got/Knot)
+	}
+	if got := headWind.SpeedOverGround(p, 270, testStart); math.Abs(got-12*Knot) > 1e-9 {
+		t.Errorf("Expected 12 knots in a tail wind, got %v knots", got/Knot)
+	}
+
+	// current of 3 knots setting to the north
+	current := Conditions{Polar: ConstantPolar(5), Currents: uniform(Vector{V: 3 * Knot})}
+	if got := current.SpeedOverGround(p, 0, testStart); math.Abs(got-8*Knot) > 1e-9 {
+		t.Errorf("Expected 8 knots with the current, got %v knots", got/Knot)
+	}
+	if got := current.SpeedOverGround(p, 90, testStart); math.Abs(got-4*Knot) > 1e-9 {
+		t.Errorf("Expected 4 knots across the current, got %v knots", got/Knot)
+	}
+	if got := current.SpeedOverGround(p, 180, testStart); math.Abs(got-2*Knot) > 1e-9 {
+		t.Errorf("Expected 2 knots against the current, got %v knots", got/Knot)
+	}
+}
+
+func TestTraversalTime(t *testing.T) {
+	from, to := gr.Node{Lat: 0, Lon: 0}, gr.Node{Lat: 0, Lon: 1}
+	distance := geo.NewPoint(0, 0).IntHaversine(geo.NewPoint(0, 1))
+	duration, ok := Conditions{Polar: ConstantPolar(10)}.TraversalTime(from, to, distance, testStart)
+	if want := time.Duration(float64(distance) / (10 * Knot) * float64(time.Second)); !ok || math.Abs(float64(duration-want)) > float64(time.Millisecond) {
+		t.Errorf("Expected %v in still water, got %v", want, duration)
+	}
+	if _, ok := (Conditions{Polar: ConstantPolar(0)}).TraversalTime(from, to, distance, testStart); ok {
+		t.Errorf("A vessel without speed cannot traverse an edge")
+	}
+}